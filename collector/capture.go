@@ -0,0 +1,457 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	//requestGet name of GET request in capture file
+	requestGet = "get"
+
+	//requestGetNext name of GETNEXT request in capture file
+	requestGetNext = "getnext"
+)
+
+//capturedExchange request sent to SNMP agent with its response in capture file, capture file contains one exchange (JSON object) per line,
+//reads of OID in walk and table modes are recorded as sequences of GETNEXT requests
+type capturedExchange struct {
+	Agent    string        `json:"agent"`
+	Request  string        `json:"request"`
+	Oid      string        `json:"oid"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+
+	//ErrorStatus, ErrorIndex and VarBinds are fields of response PDU
+	ErrorStatus int               `json:"error_status,omitempty"`
+	ErrorIndex  int               `json:"error_index,omitempty"`
+	VarBinds    []capturedVarBind `json:"varbinds,omitempty"`
+
+	//Error is set if response is not received, ErrorCategory, ErrorCause and Report are set so error is replayed with the same type
+	Error         string `json:"error,omitempty"`
+	ErrorCategory string `json:"error_category,omitempty"`
+	ErrorCause    string `json:"error_cause,omitempty"`
+	Report        string `json:"report,omitempty"`
+}
+
+//capturedVarBind variable binding in capture file, octets are set for octet strings and value is set for other types
+type capturedVarBind struct {
+	Oid    string `json:"oid"`
+	Type   string `json:"type"`
+	Value  string `json:"value,omitempty"`
+	Octets []byte `json:"octets,omitempty"`
+}
+
+//replay exchanges read from capture file, responses for the same request are served in recorded order and the last one is repeated
+type replay struct {
+	exchanges map[string][]capturedExchange
+	positions map[string]int
+	agents    map[string]bool
+}
+
+//captureWriter capture file which is open for recording, it is shared by handlers which record into the same file
+type captureWriter struct {
+	mtx  sync.Mutex
+	file *os.File
+
+	//users number of handlers which record into capture file, it is guarded by mutex of capturingSnmp
+	users int
+}
+
+//requestSender SNMP layer which sends single requests, capture records and replays single requests (PDUs)
+type requestSender interface {
+	snmpInterface
+	sendRequest(handler *snmpgo.SNMP, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error)
+}
+
+//capturingSnmp records exchanges with SNMP agents into capture files and serves exchanges from capture files instead of network,
+//SNMP agents without capture mode are passed through to SNMP layer
+type capturingSnmp struct {
+	snmp     requestSender
+	mtx      sync.Mutex
+	handlers map[*snmpgo.SNMP]configReader.SnmpAgent
+	replays  map[string]*replay
+	writers  map[string]*captureWriter
+}
+
+func newCapturingSnmp(s requestSender) *capturingSnmp {
+	return &capturingSnmp{snmp: s, handlers: make(map[*snmpgo.SNMP]configReader.SnmpAgent), replays: make(map[string]*replay),
+		writers: make(map[string]*captureWriter)}
+}
+
+func (c *capturingSnmp) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
+	switch hostConfig.CaptureMode {
+	case "":
+		return c.snmp.newHandler(hostConfig)
+	case configReader.CaptureModeReplay:
+		//handler of replay is not opened, it identifies SNMP agent only
+		if _, err := c.getReplay(hostConfig.CaptureFile); err != nil {
+			return nil, err
+		}
+		handler := &snmpgo.SNMP{}
+		c.mtx.Lock()
+		c.handlers[handler] = hostConfig
+		c.mtx.Unlock()
+		return handler, nil
+	}
+
+	handler, err := c.snmp.newHandler(hostConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	writer, ok := c.writers[hostConfig.CaptureFile]
+	if !ok {
+		f, err := os.OpenFile(hostConfig.CaptureFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			c.snmp.closeHandler(handler)
+			return nil, err
+		}
+		writer = &captureWriter{file: f}
+		c.writers[hostConfig.CaptureFile] = writer
+	}
+	writer.users++
+	c.handlers[handler] = hostConfig
+	return handler, nil
+}
+
+func (c *capturingSnmp) readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	c.mtx.Lock()
+	_, ok := c.handlers[handler]
+	c.mtx.Unlock()
+	if !ok {
+		return c.snmp.readElements(handler, oid, mode)
+	}
+
+	return snmp.Walk(func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+		return c.sendRequest(handler, pduType, oid)
+	}, oid, mode)
+}
+
+//sendRequest sends single request to SNMP agent and records it or serves response from capture file
+func (c *capturingSnmp) sendRequest(handler *snmpgo.SNMP, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+	c.mtx.Lock()
+	hostConfig, ok := c.handlers[handler]
+	writer := c.writers[hostConfig.CaptureFile]
+	c.mtx.Unlock()
+
+	if !ok {
+		return c.snmp.sendRequest(handler, pduType, oid)
+	}
+
+	if hostConfig.CaptureMode == configReader.CaptureModeReplay {
+		return c.replayRequest(hostConfig, pduType, oid)
+	}
+
+	start := time.Now()
+	pdu, err := c.snmp.sendRequest(handler, pduType, oid)
+	exchange := capturedExchange{Agent: hostConfig.Address, Request: requestName(pduType), Oid: oid, Time: start, Duration: time.Since(start)}
+	if recordErr := writer.record(exchange, pdu, err); recordErr != nil {
+		log.WithFields(log.Fields{"capture_file": hostConfig.CaptureFile}).Warn(recordErr)
+	}
+	return pdu, err
+}
+
+//closeHandler removes handler of SNMP agent, capture file is closed with the last handler which records into it,
+//handler of replay is not passed to SNMP layer because it is not opened
+func (c *capturingSnmp) closeHandler(handler *snmpgo.SNMP) {
+	c.mtx.Lock()
+	hostConfig, ok := c.handlers[handler]
+	delete(c.handlers, handler)
+	if ok && hostConfig.CaptureMode == configReader.CaptureModeRecord {
+		writer := c.writers[hostConfig.CaptureFile]
+		writer.users--
+		if writer.users == 0 {
+			delete(c.writers, hostConfig.CaptureFile)
+			writer.close()
+		}
+	}
+	c.mtx.Unlock()
+
+	if ok && hostConfig.CaptureMode == configReader.CaptureModeReplay {
+		return
+	}
+	c.snmp.closeHandler(handler)
+}
+
+//record appends exchange with response PDU or error to capture file
+func (w *captureWriter) record(exchange capturedExchange, pdu snmpgo.Pdu, err error) error {
+	if pdu != nil {
+		exchange.ErrorStatus = int(pdu.ErrorStatus())
+		exchange.ErrorIndex = pdu.ErrorIndex()
+		for _, varBind := range pdu.VarBinds() {
+			exchange.VarBinds = append(exchange.VarBinds, encodeVarBind(varBind))
+		}
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+		exchange.ErrorCategory = snmp.Category(err)
+		switch e := err.(type) {
+		case *snmp.TimeoutError:
+			exchange.ErrorCause = e.Cause.Error()
+		case *snmp.AuthError:
+			exchange.ErrorCause = e.Cause.Error()
+			exchange.Report = e.Report
+		case *snmp.TransportError:
+			exchange.ErrorCause = e.Cause.Error()
+		case *snmp.MalformedResponseError:
+			//message of malformed response is recorded as cause, cause of it is a part of error
+			exchange.ErrorCause = e.Message
+			if e.Cause != nil {
+				exchange.ErrorCause += ": " + e.Cause.Error()
+			}
+		}
+	}
+
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+
+	//exchanges of concurrent collections are written one by one
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	_, err = w.file.Write(append(line, '\n'))
+	return err
+}
+
+//close closes capture file
+func (w *captureWriter) close() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if err := w.file.Close(); err != nil {
+		log.WithFields(log.Fields{"capture_file": w.file.Name()}).Warn(err)
+	}
+}
+
+//getReplay returns exchanges read from capture file, capture file is read once
+func (c *capturingSnmp) getReplay(path string) (*replay, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if r, ok := c.replays[path]; ok {
+		return r, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := &replay{exchanges: make(map[string][]capturedExchange), positions: make(map[string]int), agents: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var exchange capturedExchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("Capture file (%s) cannot be read, incorrect exchange in line %d: %v", path, line, err)
+		}
+		key := replayKey(exchange.Agent, exchange.Request, exchange.Oid)
+		r.exchanges[key] = append(r.exchanges[key], exchange)
+		r.agents[exchange.Agent] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	c.replays[path] = r
+	return r, nil
+}
+
+//replayRequest returns response PDU for request from capture file, exchanges of the only SNMP agent in capture file are used
+//if address of SNMP agent is different than recorded one (e.g. host name which was resolved in recording)
+func (c *capturingSnmp) replayRequest(hostConfig configReader.SnmpAgent, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+	r, err := c.getReplay(hostConfig.CaptureFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	agent := hostConfig.Address
+	if !r.agents[agent] && len(r.agents) == 1 {
+		for recorded := range r.agents {
+			agent = recorded
+		}
+	}
+
+	key := replayKey(agent, requestName(pduType), oid)
+	exchanges := r.exchanges[key]
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("Request %s for OID (%s) to SNMP agent (%s) is not found in capture file (%s)", requestName(pduType), oid,
+			hostConfig.Address, hostConfig.CaptureFile)
+	}
+
+	exchange := exchanges[r.positions[key]]
+	if r.positions[key] < len(exchanges)-1 {
+		r.positions[key]++
+	}
+
+	if exchange.Error != "" {
+		return nil, replayError(exchange)
+	}
+
+	pdu := snmpgo.NewPdu(snmpgo.V2c, snmpgo.GetResponse)
+	pdu.SetErrorStatus(snmpgo.ErrorStatus(exchange.ErrorStatus))
+	pdu.SetErrorIndex(exchange.ErrorIndex)
+	for _, captured := range exchange.VarBinds {
+		varBind, err := decodeVarBind(captured)
+		if err != nil {
+			return nil, fmt.Errorf("Capture file (%s) contains incorrect variable binding: %v", hostConfig.CaptureFile, err)
+		}
+		pdu.AppendVarBind(varBind.Oid, varBind.Variable)
+	}
+	return pdu, nil
+}
+
+//replayError returns error of SNMP layer with the type of recorded error
+func replayError(exchange capturedExchange) error {
+	cause := errors.New(exchange.ErrorCause)
+	switch exchange.ErrorCategory {
+	case snmp.CategoryTimeout:
+		return &snmp.TimeoutError{Cause: cause}
+	case snmp.CategoryAuth:
+		return &snmp.AuthError{Report: exchange.Report, Cause: cause}
+	case snmp.CategoryTransport:
+		return &snmp.TransportError{Cause: cause}
+	case snmp.CategoryMalformed:
+		return &snmp.MalformedResponseError{Message: exchange.ErrorCause}
+	}
+	return errors.New(exchange.Error)
+}
+
+//replayKey returns key of request in replay
+func replayKey(agent string, request string, oid string) string {
+	return agent + " " + request + " " + oid
+}
+
+//requestName returns name of request in capture file
+func requestName(pduType snmpgo.PduType) string {
+	if pduType == snmpgo.GetRequest {
+		return requestGet
+	}
+	return requestGetNext
+}
+
+//encodeVarBind encodes variable binding for capture file
+func encodeVarBind(result *snmpgo.VarBind) capturedVarBind {
+	captured := capturedVarBind{Oid: result.Oid.String(), Type: result.Variable.Type()}
+	switch v := result.Variable.(type) {
+	case *snmpgo.OctetString:
+		captured.Octets = v.Value
+	case *snmpgo.Ipaddress:
+		captured.Octets = v.Value
+	case *snmpgo.Opaque:
+		captured.Octets = v.Value
+	case *snmpgo.Null, *snmpgo.NoSucheObject, *snmpgo.NoSucheInstance, *snmpgo.EndOfMibView:
+	default:
+		//numbers are recorded in decimal form, OIDs in dotted form
+		if n, err := v.BigInt(); err == nil {
+			captured.Value = n.String()
+		} else {
+			captured.Value = v.String()
+		}
+	}
+	return captured
+}
+
+//decodeVarBind decodes variable binding from capture file
+func decodeVarBind(captured capturedVarBind) (*snmpgo.VarBind, error) {
+	oid, err := snmpgo.NewOid(captured.Oid)
+	if err != nil {
+		return nil, err
+	}
+
+	var variable snmpgo.Variable
+	switch captured.Type {
+	case "Integer":
+		value, err := strconv.ParseInt(captured.Value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		variable = snmpgo.NewInteger(int32(value))
+	case "OctetString":
+		variable = snmpgo.NewOctetString(captured.Octets)
+	case "IpAddress":
+		if len(captured.Octets) != 4 {
+			return nil, fmt.Errorf("IP address (%v) must have 4 octets", captured.Octets)
+		}
+		variable = snmpgo.NewIpaddress(captured.Octets[0], captured.Octets[1], captured.Octets[2], captured.Octets[3])
+	case "Opaque":
+		variable = snmpgo.NewOpaque(captured.Octets)
+	case "Object Identifier":
+		variable, err = snmpgo.NewOid(captured.Value)
+		if err != nil {
+			return nil, err
+		}
+	case "Counter32", "Gauge32", "TimeTicks":
+		value, err := strconv.ParseUint(captured.Value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		switch captured.Type {
+		case "Counter32":
+			variable = snmpgo.NewCounter32(uint32(value))
+		case "Gauge32":
+			variable = snmpgo.NewGauge32(uint32(value))
+		default:
+			variable = snmpgo.NewTimeTicks(uint32(value))
+		}
+	case "Counter64":
+		value, err := strconv.ParseUint(captured.Value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		variable = snmpgo.NewCounter64(value)
+	case "Null":
+		variable = snmpgo.NewNull()
+	case "NoSucheObject":
+		variable = snmpgo.NewNoSucheObject()
+	case "NoSucheInstance":
+		variable = snmpgo.NewNoSucheInstance()
+	case "EndOfMibView":
+		variable = snmpgo.NewEndOfMibView()
+	default:
+		return nil, fmt.Errorf("Type (%s) of variable binding (%s) is not supported", captured.Type, captured.Oid)
+	}
+	return snmpgo.NewVarBind(oid, variable), nil
+}
@@ -0,0 +1,159 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+	"github.com/intelsdi-x/snap-plugin-utilities/ns"
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxCatalogInstances maximal number of metrics with concrete dynamic elements which are advertised for single metric definition
+	maxCatalogInstances = 100
+)
+
+//discoverMetricTypes returns metric types which are supported by SNMP agent set in configuration, metrics whose OIDs
+//do not exist in SNMP agent are omitted and metrics with dynamic elements are advertised also with concrete elements
+func discoverMetricTypes(cfg plugin.Config, metricsConfigs map[string]configReader.Metric, namespaces []plugin.Namespace) ([]plugin.Metric, error) {
+	agentConfig, err := configReader.GetSnmpAgentConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := acquireConnection(agentConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseConnection(conn)
+
+	conn.mtx.Lock()
+	defer conn.mtx.Unlock()
+
+	cache := newTableCache(conn.labels)
+
+	mts := []plugin.Metric{}
+	for _, namespace := range namespaces {
+		metricCfg := metricsConfigs[namespace.String()]
+
+		supported, err := supportedOid(conn.handler, metricCfg)
+		if err != nil {
+			return nil, err
+		}
+		if !supported {
+			log.WithFields(log.Fields{"namespace": namespace.String(), "OID": metricCfg.Oid}).Debug("Metric is not supported by SNMP agent")
+			continue
+		}
+
+		mts = append(mts, plugin.Metric{Namespace: namespace, Description: metricCfg.Description, Unit: metricCfg.Unit})
+
+		for _, instance := range catalogInstances(conn.handler, cache, namespace, metricCfg) {
+			mts = append(mts, plugin.Metric{Namespace: instance, Description: metricCfg.Description, Unit: metricCfg.Unit})
+		}
+	}
+	return mts, nil
+}
+
+//supportedOid checks if SNMP agent has object of metric, subtree of OID (or of fallback OID) is checked with GETNEXT request
+//and scalar is checked with GET request, error is returned if SNMP agent does not respond
+func supportedOid(handler *snmpgo.SNMP, cfg configReader.Metric) (bool, error) {
+	mode := snmp.ModeNext
+	if cfg.Mode == configReader.ModeSingle {
+		mode = configReader.ModeSingle
+	}
+
+	for _, oid := range []string{cfg.Oid, cfg.FallbackOid} {
+		if oid == "" {
+			continue
+		}
+
+		results, err := snmp_.readElements(handler, oid, mode)
+		if err != nil && !snmp.Responded(err) {
+			return false, err
+		}
+		if notAvailable(results, err) {
+			continue
+		}
+		if mode == configReader.ModeSingle || inSubtree(results[0].Oid.String(), oid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//inSubtree checks if OID is in subtree of root OID
+func inSubtree(oid string, root string) bool {
+	return strings.HasPrefix(strings.Trim(oid, ".")+".", strings.Trim(root, ".")+".")
+}
+
+//catalogInstances returns namespaces with concrete dynamic elements read from SNMP agent, namespaces with elements
+//which are not allowed by Snap in static elements of catalog (e.g. IP addresses) are omitted
+func catalogInstances(handler *snmpgo.SNMP, cache *tableCache, namespace plugin.Namespace, cfg configReader.Metric) []plugin.Namespace {
+	if isDynamic, _ := namespace.IsDynamic(); !isDynamic {
+		return nil
+	}
+
+	results, err := readMetric(handler, &cfg)
+	if notAvailable(results, err) {
+		return nil
+	}
+
+	values, err := getDynamicNamespaceElements(handler, cache, results, &cfg)
+	if err != nil {
+		return nil
+	}
+
+	offset := len(plugin.NewNamespace(Vendor, PluginName))
+	instances := []plugin.Namespace{}
+	unique := map[string]bool{}
+	for i := range results {
+		instance := plugin.NewNamespace(namespace.Strings()...)
+		allowed := true
+		for j, element := range cfg.Namespace {
+			if element.Source == configReader.NsSourceString {
+				continue
+			}
+			value := values[j][i]
+			if value == "" || value == wildcard || ns.ReplaceNotAllowedCharsInNamespacePart(value) != value {
+				allowed = false
+				break
+			}
+			instance[j+offset].Value = value
+		}
+
+		if !allowed || unique[instance.String()] {
+			continue
+		}
+		if len(instances) == maxCatalogInstances {
+			log.WithFields(log.Fields{"namespace": namespace.String()}).Debug("Number of metrics with concrete dynamic elements in catalog is limited")
+			break
+		}
+		unique[instance.String()] = true
+		instances = append(instances, instance)
+	}
+	return instances
+}
@@ -21,18 +21,18 @@ limitations under the License.
 package collector
 
 import (
+	"encoding/json"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/profiles"
 	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
 	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
 	"github.com/intelsdi-x/snap-plugin-utilities/ns"
-	"github.com/intelsdi-x/snap/core"
 	"github.com/k-sone/snmpgo"
 	log "github.com/sirupsen/logrus"
 )
@@ -50,6 +50,12 @@ const (
 	// setFileConfigVar configuration variable to define path to setfile
 	setFileConfigVar = "setfile"
 
+	// profileConfigVar configuration variable to define comma separated list of built-in profiles
+	profileConfigVar = "profile"
+
+	// discoverCatalogConfigVar configuration variable to enable catalog of metric types which are supported by SNMP agent
+	discoverCatalogConfigVar = "discover_catalog"
+
 	// tagSnmpAgentName indicates SNMP agent name, tag which is added to metrics
 	tagSnmpAgentName = "SNMP_AGENT_NAME"
 
@@ -59,6 +65,18 @@ const (
 	// tagOid indicates metric OID, tag which is added to metrics
 	tagOid = "OID"
 
+	// tagPreviousValue indicates value of metric in previous collection, tag which is added to metrics returned on change of value
+	tagPreviousValue = "PREVIOUS_VALUE"
+
+	// tagSnmpCredentialSet indicates credential set which is in use, tag which is added to metrics if credential sets are configured
+	tagSnmpCredentialSet = "SNMP_CREDENTIAL_SET"
+
+	// tagSysObjectID indicates sysObjectID of SNMP agent, tag which is added to metrics collected from discovered SNMP agents
+	tagSysObjectID = "SYS_OBJECT_ID"
+
+	// probeOid OID which is read to check if credential set is accepted by SNMP agent (sysObjectID)
+	probeOid = ".1.3.6.1.2.1.1.2.0"
+
 	// the max time a connection can be unused.
 	connectionIdle = time.Minute * 30
 
@@ -68,14 +86,35 @@ const (
 
 // Plugin main structure
 type Plugin struct {
-	initialized    bool
-	metricsConfigs map[string]configReader.Metric
+	//metricsConfigs configurations of metrics by namespace, they are read once for each definition of metrics (setfile and profiles),
+	//mtx guards metricsConfigs because metrics can be collected concurrently by many tasks
+	mtx            sync.RWMutex
+	metricsConfigs map[string]map[string]configReader.Metric
+
+	//matchers compiled matchers of namespaces requested in tasks
+	matchers    map[string]namespaceMatcher
+	mtxMatchers sync.Mutex
 }
 
 type connection struct {
-	handler  *snmpgo.SNMP
-	mtx      *sync.Mutex
-	lastUsed time.Time
+	handler       *snmpgo.SNMP
+	mtx           *sync.Mutex
+	lastUsed      time.Time
+	credentialSet string
+
+	//labels cache of namespace elements and tags which are read from SNMP agent
+	labels *labelCache
+
+	//state values of metrics from previous collection
+	state *metricState
+
+	//address IP address and port which is used by the connection, connection is reopened if host name is resolved to different IP address
+	address string
+
+	//users number of collections which use the connection, closed indicates that the connection is closed when the last of them ends,
+	//both fields and lastUsed are guarded by mtxSnmpConnections
+	users  int
+	closed bool
 }
 
 type snmpType struct{}
@@ -83,11 +122,12 @@ type snmpType struct{}
 type snmpInterface interface {
 	newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error)
 	readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error)
+	closeHandler(handler *snmpgo.SNMP)
 }
 
 var (
-	snmp_              = snmpInterface(&snmpType{})
-	snmpConnections    = make(map[string]connection)
+	snmp_              = snmpInterface(newCapturingSnmp(&snmpType{}))
+	snmpConnections    = make(map[string]*connection)
 	mtxSnmpConnections = &sync.Mutex{}
 )
 
@@ -97,20 +137,169 @@ func init() {
 
 // New creates initialized instance of snmp collector
 func New() *Plugin {
-	return &Plugin{metricsConfigs: make(map[string]configReader.Metric)}
+	return &Plugin{metricsConfigs: make(map[string]map[string]configReader.Metric), matchers: make(map[string]namespaceMatcher)}
 }
 
 // GetMetricTypes returns list of available metric types
 // It returns error in case retrieval was not successful
 func (p *Plugin) GetMetricTypes(cfg plugin.Config) ([]plugin.Metric, error) {
+	key, err := metricsConfigsKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	configs, err := getMetricsConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	metricsConfigs, namespaces := indexMetricsConfigs(configs)
+
+	p.mtx.Lock()
+	p.metricsConfigs[key] = metricsConfigs
+	p.mtx.Unlock()
+
+	//catalog is built from definitions of metrics if SNMP agent cannot be reached
+	if discoverCatalog, _ := cfg.GetBool(discoverCatalogConfigVar); discoverCatalog {
+		mts, err := discoverMetricTypes(cfg, metricsConfigs, namespaces)
+		if err == nil {
+			return mts, nil
+		}
+		log.WithFields(log.Fields{"parameter": discoverCatalogConfigVar}).Warn(fmt.Errorf("Metric types cannot be discovered in SNMP agent, all defined metric types are returned: %v", err))
+	}
+
 	mts := []plugin.Metric{}
-	for _, cfg := range configs {
+	for _, namespace := range namespaces {
+		mt := plugin.Metric{
+			Namespace:   namespace,
+			Description: metricsConfigs[namespace.String()].Description,
+			Unit:        metricsConfigs[namespace.String()].Unit,
+		}
+		mts = append(mts, mt)
+	}
+	return mts, nil
+}
+
+// CollectMetrics returns list of requested metric values
+// It returns error in case retrieval was not successful
+func (p *Plugin) CollectMetrics(metrics []plugin.Metric) ([]plugin.Metric, error) {
+	//metrics are grouped by configuration, each group is collected from SNMP agent set in its configuration
+	groups := groupMetrics(metrics)
 
+	var err error
+	failed := 0
+	mts := []plugin.Metric{}
+	for _, group := range groups {
+		var groupMts []plugin.Metric
+		groupMts, err = p.collectGroup(group)
+		if err != nil {
+			failed++
+			if len(groups) > 1 {
+				log.WithFields(log.Fields{"namespace": group[0].Namespace.String()}).Warn(err)
+			}
+			continue
+		}
+		mts = append(mts, groupMts...)
+	}
+
+	//error is returned if none of groups is collected
+	if failed == len(groups) && err != nil {
+		return nil, err
+	}
+	return mts, nil
+}
+
+//collectGroup collects metrics which have the same configuration
+func (p *Plugin) collectGroup(metrics []plugin.Metric) ([]plugin.Metric, error) {
+	metricsConfigs, err := p.getMetricsConfigs(metrics[0].Config)
+	if err != nil {
+		return nil, err
+	}
+
+	discovery, ok, err := configReader.GetDiscoveryConfig(metrics[0].Config)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return p.collectDiscoveredMetrics(metrics, metricsConfigs, discovery)
+	}
+
+	agentConfig, err := configReader.GetSnmpAgentConfig(metrics[0].Config)
+	if err != nil {
+		return nil, err
+	}
+	return p.collectAgentMetrics(metrics, metricsConfigs, agentConfig, nil)
+}
+
+//groupMetrics groups requested metrics by their configuration, order of groups is the order of the first metrics in groups
+func groupMetrics(metrics []plugin.Metric) [][]plugin.Metric {
+	groups := [][]plugin.Metric{}
+	indexes := map[string]int{}
+	for _, metric := range metrics {
+		//keys of JSON object are sorted, so the same configurations are encoded in the same way
+		key, err := json.Marshal(metric.Config)
+		if err != nil {
+			key = []byte(fmt.Sprintf("%v", metric.Config))
+		}
+
+		i, ok := indexes[string(key)]
+		if !ok {
+			i = len(groups)
+			indexes[string(key)] = i
+			groups = append(groups, []plugin.Metric{})
+		}
+		groups[i] = append(groups[i], metric)
+	}
+	return groups
+}
+
+//getMetricsConfigs returns configurations of metrics by namespace, configurations are read once for each definition of metrics
+func (p *Plugin) getMetricsConfigs(config plugin.Config) (map[string]configReader.Metric, error) {
+	key, err := metricsConfigsKey(config)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mtx.RLock()
+	metricsConfigs, ok := p.metricsConfigs[key]
+	p.mtx.RUnlock()
+	if ok {
+		return metricsConfigs, nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if metricsConfigs, ok := p.metricsConfigs[key]; ok {
+		return metricsConfigs, nil
+	}
+
+	configs, err := getMetricsConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	metricsConfigs, _ = indexMetricsConfigs(configs)
+	p.metricsConfigs[key] = metricsConfigs
+	return metricsConfigs, nil
+}
+
+//metricsConfigsKey returns key of definition of metrics, it is built from setfile and profiles set in configuration
+func metricsConfigsKey(config plugin.Config) (string, error) {
+	discoveryProfileNames, err := configReader.GetDiscoveryProfileNames(config)
+	if err != nil {
+		return "", err
+	}
+	setFilePath, _ := config.GetString(setFileConfigVar)
+	profileNames, _ := config.GetString(profileConfigVar)
+	return strings.Join([]string{setFilePath, profileNames, strings.Join(discoveryProfileNames, ",")}, "\n"), nil
+}
+
+//indexMetricsConfigs returns configurations of metrics by namespace and namespaces in order of definition,
+//only the first of metrics which expose the same namespace is used
+func indexMetricsConfigs(configs configReader.Metrics) (map[string]configReader.Metric, []plugin.Namespace) {
+	metricsConfigs := make(map[string]configReader.Metric)
+	namespaces := []plugin.Namespace{}
+	for _, cfg := range configs {
 		namespace := plugin.NewNamespace(Vendor, PluginName)
 		for _, ns := range cfg.Namespace {
 			if ns.Source == configReader.NsSourceString {
@@ -120,117 +309,177 @@ func (p *Plugin) GetMetricTypes(cfg plugin.Config) ([]plugin.Metric, error) {
 			}
 		}
 
-		if _, metricExist := p.metricsConfigs[namespace.String()]; metricExist {
+		if _, metricExist := metricsConfigs[namespace.String()]; metricExist {
 			logFields := map[string]interface{}{
 				"namespace":                     namespace.String(),
-				"previous_metric_configuration": p.metricsConfigs[namespace.String()],
+				"previous_metric_configuration": metricsConfigs[namespace.String()],
 				"current_metric_configuration":  cfg,
 			}
 			log.WithFields(logFields).Warn(fmt.Errorf("Plugin configuration file (`setfile`) contains metrics definitions which expose the same namespace, only one of them is in use. Correction of plugin configuration file (`setfile`) is recommended."))
 		} else {
-			p.metricsConfigs[namespace.String()] = cfg
-
-			mt := plugin.Metric{
-				Namespace:   namespace,
-				Description: cfg.Description,
-				Unit:        cfg.Unit,
-			}
-			mts = append(mts, mt)
-
+			metricsConfigs[namespace.String()] = cfg
+			namespaces = append(namespaces, namespace)
 		}
 	}
-	return mts, nil
+	return metricsConfigs, namespaces
 }
 
-// CollectMetrics returns list of requested metric values
-// It returns error in case retrieval was not successful
-func (p *Plugin) CollectMetrics(metrics []plugin.Metric) ([]plugin.Metric, error) {
-	var mtxMetrics sync.Mutex
-	var wgCollectedMetrics sync.WaitGroup
-
-	//initialization of plugin structure (only once)
-	if !p.initialized {
-		configs, err := getMetricsConfig(metrics[0].Config)
-		if err != nil {
-			return nil, err
+//collectDiscoveredMetrics collects metrics from SNMP agents which are found in the last sweep of discovery ranges,
+//errors of single SNMP agents are logged and metrics from other SNMP agents are returned
+func (p *Plugin) collectDiscoveredMetrics(metrics []plugin.Metric, metricsConfigs map[string]configReader.Metric, discovery configReader.Discovery) ([]plugin.Metric, error) {
+	//profiles which are selected by sysObjectID, metrics of profiles set in plugin configuration are collected from all SNMP agents
+	discoveryProfiles := map[string]bool{}
+	for _, profiles := range discovery.Profiles {
+		for _, profile := range profiles {
+			discoveryProfiles[profile] = true
 		}
-		for _, cfg := range configs {
-			namespace := core.NewNamespace(Vendor, PluginName)
-			for _, ns := range cfg.Namespace {
-				if ns.Source == configReader.NsSourceString {
-					namespace = namespace.AddStaticElement(ns.String)
-				} else {
-					namespace = namespace.AddDynamicElement(ns.Name, ns.Description)
-				}
-			}
-
-			if _, metricExist := p.metricsConfigs[namespace.String()]; metricExist {
-				logFields := map[string]interface{}{
-					"namespace":                     namespace.String(),
-					"previous_metric_configuration": p.metricsConfigs[namespace.String()],
-					"current_metric_configuration":  cfg,
-				}
-				log.WithFields(logFields).Warn(fmt.Errorf("Plugin configuration file (`setfile`) contains metrics definitions which expose the same namespace, only one of them is in use. Correction of plugin configuration file (`setfile`) is recommended."))
-			} else {
-				//add metric configuration to plugin metric map
-				p.metricsConfigs[namespace.String()] = cfg
-			}
+	}
+	if profileNames, err := metrics[0].Config.GetString(profileConfigVar); err == nil {
+		for _, name := range strings.Split(profileNames, ",") {
+			delete(discoveryProfiles, strings.TrimSpace(name))
 		}
-		p.initialized = true
 	}
 
-	agentConfig, err := configReader.GetSnmpAgentConfig(metrics[0].Config)
-	if err != nil {
-		return nil, err
+	mts := []plugin.Metric{}
+	for _, t := range getDiscoverer(discovery).getTargets(discovery) {
+		t := t
+		agentMts, err := p.collectAgentMetrics(metrics, metricsConfigs, t.agentConfig, func(cfg configReader.Metric) bool {
+			return discoveredMetric(cfg, t, discoveryProfiles)
+		})
+		if err != nil {
+			log.WithFields(log.Fields{"agent_address": t.agentConfig.Address, "sys_name": t.sysName}).Warn(err)
+			continue
+		}
+		for _, mt := range agentMts {
+			mt.Tags[tagSysObjectID] = t.sysObjectID
+			mts = append(mts, mt)
+		}
 	}
+	return mts, nil
+}
 
-	//lock using of connections in watchConnections
-	mtxSnmpConnections.Lock()
-	defer mtxSnmpConnections.Unlock()
+//collectAgentMetrics collects metrics from SNMP agent, filter selects configurations of metrics which are collected (all if it is nil)
+func (p *Plugin) collectAgentMetrics(metrics []plugin.Metric, metricsConfigs map[string]configReader.Metric, agentConfig configReader.SnmpAgent,
+	filter func(configReader.Metric) bool) ([]plugin.Metric, error) {
+	var mtxMetrics sync.Mutex
+	var wgCollectedMetrics sync.WaitGroup
 
-	conn, err := getConnection(agentConfig)
+	conn, err := acquireConnection(agentConfig)
 	if err != nil {
 		return nil, err
 	}
+	defer releaseConnection(conn)
+
+	//errors of reads in collection decide if the remaining reads are skipped and if the connection is opened again
+	errs := newCollectionErrors()
 
 	mts := []plugin.Metric{}
 
-	for _, metric := range metrics {
+	//columns of tables which are joined with metrics are read once in collection
+	cache := newTableCache(conn.labels)
+
+	//each configuration of metric is read once in collection, also if it is selected by many requested metrics
+	//(e.g. by `/intel/snmp/*` and by namespace with concrete elements advertised in discovered catalog)
+	requested := map[string]*requestedConfig{}
+	selected := make([][]*requestedConfig, len(metrics))
+	for i, metric := range metrics {
 
 		//get metrics to collect
-		metricsConfigs, err := getMetricsToCollect(metric.Namespace.String(), p.metricsConfigs)
+		matcher := p.getMatcher(metric.Namespace)
+		collectedConfigs, err := getMetricsToCollect(matcher, metricsConfigs)
 		if err != nil {
 			return nil, err
 		}
 
-		wgCollectedMetrics.Add(len(metricsConfigs))
+		for ns, cfg := range collectedConfigs {
+			if filter != nil && !filter(cfg) {
+				continue
+			}
+			if _, ok := requested[ns]; !ok {
+				requested[ns] = &requestedConfig{cfg: cfg}
+				selected[i] = append(selected[i], requested[ns])
+			}
+			requested[ns].metrics = append(requested[ns].metrics, metric)
+			requested[ns].matchers = append(requested[ns].matchers, matcher)
+		}
+	}
 
-		for _, cfg := range metricsConfigs {
+	//counters are reset by restart of SNMP agent, so rates are not calculated across restart
+	for _, r := range requested {
+		if !r.cfg.Rate {
+			continue
+		}
+		conn.mtx.Lock()
+		if ticks, ok := cache.uptime(conn.handler); ok {
+			conn.state.checkSysUpTime(ticks)
+		}
+		conn.mtx.Unlock()
+		break
+	}
+
+	//configurations are read in order of requested metrics which select them first
+	for _, requestedConfigs := range selected {
 
-			go func(cfg configReader.Metric) {
+		wgCollectedMetrics.Add(len(requestedConfigs))
+
+		for _, r := range requestedConfigs {
+
+			go func(cfg configReader.Metric, r *requestedConfig) {
 
 				defer wgCollectedMetrics.Done()
 
 				conn.mtx.Lock()
 
+				//SNMP agent which did not respond in time is not asked again in this collection
+				if errs.skip() {
+					conn.mtx.Unlock()
+					return
+				}
+
 				//get value of metric/metrics
-				results, err := snmp_.readElements(conn.handler, cfg.Oid, cfg.Mode)
+				results, err := readWithRetry(conn, &cfg, errs)
 				if err != nil {
-					log.Warn(err)
+					if _, ok := err.(*snmp.TooBigError); ok {
+						log.WithFields(log.Fields{"OID": cfg.Oid}).Error(err)
+					} else {
+						log.Warn(err)
+					}
 					conn.mtx.Unlock()
 					return
 				}
 
 				//get dynamic elements of namespace parts
-				err = getDynamicNamespaceElements(conn.handler, results, &cfg)
+				values, err := getDynamicNamespaceElements(conn.handler, cache, results, &cfg)
+				if err != nil {
+					conn.mtx.Unlock()
+					return
+				}
+
+				//get values of tags configured for metric
+				tags := getTags(conn.handler, cache, results, &cfg)
+
+				//get subtypes of metric values which are decoded
+				var subtypes []string
+				if cfg.SubtypeOid != "" {
+					subtypes, err = resolveJoin(conn.handler, cache, results, &cfg, join{oid: cfg.SubtypeOid, join: configReader.JoinIndex})
+					if err != nil {
+						log.WithFields(log.Fields{"OID": cfg.Oid, "subtype_OID": cfg.SubtypeOid}).Warn(err)
+						conn.mtx.Unlock()
+						return
+					}
+				}
+
+				//get values which modify metric values (e.g. divisors)
+				operations, err := readOperations(conn.handler, cache, results, &cfg)
 				if err != nil {
 					conn.mtx.Unlock()
 					return
 				}
 
-				conn.lastUsed = time.Now()
 				conn.mtx.Unlock()
 
+				timestamp := time.Now()
+
 				for i, result := range results {
 
 					//build namespace for metric
@@ -241,16 +490,57 @@ func (p *Plugin) CollectMetrics(metrics []plugin.Metric) ([]plugin.Metric, error
 							namespace = namespace.AddStaticElements(ns.String)
 						} else {
 							namespace = namespace.AddDynamicElement(ns.Name, ns.Description)
-							namespace[j+offset].Value = ns.Values[i]
+							namespace[j+offset].Value = values[j][i]
 						}
 					}
 
+					//filter specific instance
+					metric, ok := r.requestedBy(namespace.Strings())
+					if !ok {
+						continue
+					}
+
+					//special values are not returned
+					if skipValue(cfg.SkipValues, result.Variable.String()) {
+						continue
+					}
+
 					//convert metric types
 					val, err := convertSnmpDataToMetric(result.Variable.String(), result.Variable.Type())
 					if err != nil {
 						continue
 					}
 
+					if subtypes != nil {
+						val = decodeValue(result.Variable, cfg.SubtypeEncoding[subtypes[i]])
+					}
+
+					//metric is returned on change of value only, it is available from the second collection
+					var previous interface{}
+					if cfg.Change {
+						var changed bool
+						if previous, changed = conn.state.change(namespace.String(), val); !changed {
+							continue
+						}
+					}
+
+					if cfg.ValueMap != nil {
+						val = mapMetricValue(cfg.ValueMap, val)
+					}
+
+					//rate is available from the second collection
+					if cfg.Rate {
+						rate, ok := conn.state.rate(namespace.String(), val, result.Variable.Type(), timestamp)
+						if !ok {
+							continue
+						}
+						val = rate
+					}
+
+					if val, err = applyOperations(val, operations, i); err != nil {
+						continue
+					}
+
 					//modify numeric metric - use scale and shift parameters
 					data := modifyNumericMetric(val, cfg.Scale, cfg.Shift)
 
@@ -258,7 +548,7 @@ func (p *Plugin) CollectMetrics(metrics []plugin.Metric) ([]plugin.Metric, error
 					mt := plugin.Metric{
 						Namespace: namespace,
 						Data:      data,
-						Timestamp: time.Now(),
+						Timestamp: timestamp,
 						Tags: map[string]string{
 							tagSnmpAgentName:    agentConfig.Name,
 							tagSnmpAgentAddress: agentConfig.Address,
@@ -267,28 +557,39 @@ func (p *Plugin) CollectMetrics(metrics []plugin.Metric) ([]plugin.Metric, error
 						Description: metric.Description,
 					}
 
-					//adding metric to list of metrics
-					mtxMetrics.Lock()
+					if len(agentConfig.CredentialSets) > 0 {
+						mt.Tags[tagSnmpCredentialSet] = conn.credentialSet
+					}
 
-					//filter specific instance
-					nsPattern := strings.Replace(metric.Namespace.String(), "*", ".*", -1)
-					matched, err := regexp.MatchString(nsPattern, mt.Namespace.String())
-					if err != nil {
-						logFields := map[string]interface{}{"namespace": mt.Namespace.String(), "pattern": nsPattern, "match_error": err}
-						err := fmt.Errorf("Cannot parse namespace element for matching")
-						log.WithFields(logFields).Warn(err)
-						return
+					if cfg.Change {
+						mt.Tags[tagPreviousValue] = fmt.Sprint(mapMetricValue(cfg.ValueMap, previous))
 					}
-					if matched {
-						mts = append(mts, mt)
+
+					//tags configured for metric do not override tags added by plugin
+					for k, v := range tags[i] {
+						if _, ok := mt.Tags[k]; !ok {
+							mt.Tags[k] = v
+						}
 					}
 
+					//adding metric to list of metrics
+					mtxMetrics.Lock()
+					mts = append(mts, mt)
 					mtxMetrics.Unlock()
 				}
-			}(cfg)
+			}(r.cfg, r)
 		}
 		wgCollectedMetrics.Wait()
 	}
+
+	errs.report(agentConfig.Address)
+
+	if (errs.reprobe && len(agentConfig.CredentialSets) > 0) || errs.reauthenticate {
+		//remove the connection, credential sets are probed again and SNMPv3 engine is discovered again in the next collection
+		mtxSnmpConnections.Lock()
+		closeConnection(connectionKey(agentConfig), conn)
+		mtxSnmpConnections.Unlock()
+	}
 	return mts, nil
 }
 
@@ -297,14 +598,75 @@ func (p *Plugin) CollectMetrics(metrics []plugin.Metric) ([]plugin.Metric, error
 func (p *Plugin) GetConfigPolicy() (plugin.ConfigPolicy, error) {
 	policy := plugin.NewConfigPolicy()
 
-	err := policy.AddNewStringRule([]string{Vendor, PluginName}, setFileConfigVar, true)
+	err := policy.AddNewStringRule([]string{Vendor, PluginName}, setFileConfigVar, false)
+	if err != nil {
+		return *policy, err
+	}
+
+	err = policy.AddNewStringRule([]string{Vendor, PluginName}, profileConfigVar, false)
+	if err != nil {
+		return *policy, err
+	}
+
+	err = policy.AddNewBoolRule([]string{Vendor, PluginName}, discoverCatalogConfigVar, false, plugin.SetDefaultBool(false))
 	if err != nil {
 		return *policy, err
 	}
 
+	for _, parameters := range [][]configReader.ConfigParameter{configReader.SnmpAgentConfigParameters, configReader.DiscoveryConfigParameters} {
+		for _, parameter := range parameters {
+			if err := addConfigRule(policy, parameter); err != nil {
+				return *policy, err
+			}
+		}
+	}
+
 	return *policy, nil
 }
 
+//addConfigRule adds rule for configuration parameter to config policy, parameters which are required only without another
+//parameter and allowed values of string parameters cannot be declared in config policy so they are checked when configuration is read
+func addConfigRule(policy *plugin.ConfigPolicy, parameter configReader.ConfigParameter) error {
+	key := []string{Vendor, PluginName}
+	required := parameter.Required && parameter.OptionalWith == ""
+
+	if parameter.Default != nil && len(parameter.Options) > 0 {
+		allowed := false
+		for _, option := range parameter.Options {
+			if option == parameter.Default {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("Default value (%v) of configuration parameter (%s) is not one of possible options: %v",
+				parameter.Default, parameter.Name, parameter.Options)
+		}
+	}
+
+	switch parameter.Type {
+	case configReader.ParameterString:
+		opts := []plugin.StringRuleOpt{}
+		if value, ok := parameter.Default.(string); ok {
+			opts = append(opts, plugin.SetDefaultString(value))
+		}
+		return policy.AddNewStringRule(key, parameter.Name, required, opts...)
+	case configReader.ParameterInt:
+		opts := []plugin.IntRuleOpt{}
+		if value, ok := parameter.Default.(int64); ok {
+			opts = append(opts, plugin.SetDefaultInt(value))
+		}
+		if parameter.Min != nil {
+			opts = append(opts, plugin.SetMinInt(*parameter.Min))
+		}
+		if parameter.Max != nil {
+			opts = append(opts, plugin.SetMaxInt(*parameter.Max))
+		}
+		return policy.AddNewIntRule(key, parameter.Name, required, opts...)
+	default:
+		return fmt.Errorf("Unknown type (%s) of configuration parameter (%s)", parameter.Type, parameter.Name)
+	}
+}
+
 //NewHandler creates new connection with SNMP agent
 func (s *snmpType) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
 	return snmp.NewHandler(hostConfig)
@@ -315,17 +677,121 @@ func (s *snmpType) readElements(handler *snmpgo.SNMP, oid string, mode string) (
 	return snmp.ReadElements(handler, oid, mode)
 }
 
-//getConnection gets connection with SNMP agent, checks if connection with specified SNMP agent exists, if not a new connection is initialized
-func getConnection(agentConfig configReader.SnmpAgent) (connection, error) {
-	if conn, ok := snmpConnections[agentConfig.Address]; ok {
-		return conn, nil
+//sendRequest sends single request to SNMP agent
+func (s *snmpType) sendRequest(handler *snmpgo.SNMP, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+	return snmp.Request(handler, pduType, oid)
+}
+
+//closeHandler closes connection with SNMP agent
+func (s *snmpType) closeHandler(handler *snmpgo.SNMP) {
+	handler.Close()
+}
+
+//acquireConnection gets connection with SNMP agent and marks it as used, the connection must be released when collection ends
+func acquireConnection(agentConfig configReader.SnmpAgent) (*connection, error) {
+	//host name is resolved before connections are locked, so slow DNS does not block collections from other SNMP agents
+	address, err := resolveAgentAddress(agentConfig)
+	if err != nil {
+		return nil, err
 	}
+
+	mtxSnmpConnections.Lock()
+	defer mtxSnmpConnections.Unlock()
+
+	conn, err := getConnection(agentConfig, address)
+	if err != nil {
+		return nil, err
+	}
+	conn.users++
+	conn.lastUsed = time.Now()
+	return conn, nil
+}
+
+//releaseConnection marks connection as unused, the connection is closed if it was removed during collection
+func releaseConnection(conn *connection) {
+	mtxSnmpConnections.Lock()
+	defer mtxSnmpConnections.Unlock()
+
+	conn.users--
+	conn.lastUsed = time.Now()
+	if conn.closed && conn.users == 0 {
+		snmp_.closeHandler(conn.handler)
+	}
+}
+
+//closeConnection removes connection, it is closed immediately if it is not used or when the last collection which uses it ends,
+//mtxSnmpConnections must be locked
+func closeConnection(key string, conn *connection) {
+	if snmpConnections[key] == conn {
+		delete(snmpConnections, key)
+	}
+	if conn.closed {
+		return
+	}
+	conn.closed = true
+	if conn.users == 0 {
+		snmp_.closeHandler(conn.handler)
+	}
+}
+
+//getConnection gets connection with SNMP agent, checks if connection with specified SNMP agent exists, if not a new connection is initialized,
+//address is resolved address of SNMP agent, mtxSnmpConnections must be locked
+func getConnection(agentConfig configReader.SnmpAgent, address string) (*connection, error) {
+	key := connectionKey(agentConfig)
+	if conn, ok := snmpConnections[key]; ok {
+		if conn.address == address {
+			return conn, nil
+		}
+
+		//host name of SNMP agent is resolved to different IP address, the connection is reopened
+		log.WithFields(log.Fields{"agent_address": agentConfig.Address, "previous_address": conn.address, "current_address": address}).Info("SNMP agent address changed")
+		closeConnection(key, conn)
+	}
+
+	if len(agentConfig.CredentialSets) > 0 {
+		return probeCredentialSets(agentConfig, address)
+	}
+
+	agentConfig.Address = address
 	handler, err := snmp_.newHandler(agentConfig)
 	if err != nil {
-		return connection{}, err
+		return nil, err
+	}
+	snmpConnections[key] = &connection{handler: handler, mtx: &sync.Mutex{}, address: address, labels: newLabelCache(),
+		state: newMetricState()}
+	return snmpConnections[key], nil
+}
+
+//probeCredentialSets initializes connection with SNMP agent using the first credential set which is accepted by SNMP agent
+func probeCredentialSets(agentConfig configReader.SnmpAgent, address string) (*connection, error) {
+	var err error
+	key := connectionKey(agentConfig)
+	for _, credentialSet := range agentConfig.CredentialSets {
+		logFields := log.Fields{"agent_address": agentConfig.Address, "credential_set": credentialSet.CredentialSet}
+
+		var handler *snmpgo.SNMP
+		credentialSet.Address = address
+		handler, err = snmp_.newHandler(credentialSet)
+		if err != nil {
+			log.WithFields(logFields).Warn(err)
+			continue
+		}
+
+		_, err = snmp_.readElements(handler, probeOid, configReader.ModeSingle)
+		if err != nil {
+			if !snmp.Responded(err) {
+				log.WithFields(logFields).Warn(err)
+				snmp_.closeHandler(handler)
+				continue
+			}
+		}
+
+		log.WithFields(logFields).Debug("Credential set accepted by SNMP agent")
+		snmpConnections[key] = &connection{handler: handler, mtx: &sync.Mutex{}, credentialSet: credentialSet.CredentialSet, address: address,
+			labels: newLabelCache(), state: newMetricState()}
+		return snmpConnections[key], nil
 	}
-	snmpConnections[agentConfig.Address] = connection{handler: handler, mtx: &sync.Mutex{}}
-	return snmpConnections[agentConfig.Address], nil
+	return nil, fmt.Errorf("None of credential sets is accepted by SNMP agent (%s), last error: %v", agentConfig.Address, err)
 }
 
 //watchConnections observes SNMP connections and closes unused connections
@@ -333,25 +799,22 @@ func watchConnections() {
 	for {
 		time.Sleep(connectionWait)
 		mtxSnmpConnections.Lock()
-		for k := range snmpConnections {
-			if time.Now().Sub(snmpConnections[k].lastUsed) > connectionIdle {
-
-				//close the connection
-				snmpConnections[k].handler.Close()
-
-				//remove the connection
-				delete(snmpConnections, k)
+		for k, conn := range snmpConnections {
+			if conn.users == 0 && time.Now().Sub(conn.lastUsed) > connectionIdle {
+				//close and remove the connection
+				closeConnection(k, conn)
 			}
 		}
 		mtxSnmpConnections.Unlock()
 	}
 }
 
-//getDynamicNamespaceElements gets dynamic elements of namespace, either sending SNMP requests or using part of OID
-func getDynamicNamespaceElements(handler *snmpgo.SNMP, results []*snmpgo.VarBind, metric *configReader.Metric) error {
+//getDynamicNamespaceElements gets dynamic elements of namespace, either sending SNMP requests or using part of OID,
+//values of elements are returned for each of results and configuration of metric is not modified
+func getDynamicNamespaceElements(handler *snmpgo.SNMP, cache *tableCache, results []*snmpgo.VarBind, metric *configReader.Metric) ([][]string, error) {
+	values := make([][]string, len(metric.Namespace))
 	for i := 0; i < len(metric.Namespace); i++ {
-		//clear slice with dynamic parts of namespace
-		metric.Namespace[i].Values = []string{}
+		values[i] = []string{}
 
 		switch metric.Namespace[i].Source {
 
@@ -359,78 +822,166 @@ func getDynamicNamespaceElements(handler *snmpgo.SNMP, results []*snmpgo.VarBind
 			continue
 
 		case configReader.NsSourceSNMP:
-			parts, err := snmp_.readElements(handler, metric.Namespace[i].Oid, metric.Mode)
+			if metric.Namespace[i].Join != "" {
+				//namespace element is read from column of other table
+				joined, err := resolveJoin(handler, cache, results, metric, join{oid: metric.Namespace[i].Oid,
+					join: metric.Namespace[i].Join, indexOid: metric.Namespace[i].IndexOid, oidPart: metric.Namespace[i].OidPart,
+					cacheTTL: metric.Namespace[i].CacheTTL, cacheInvalidateOid: metric.Namespace[i].CacheInvalidateOid,
+					fallbackOid: metric.Namespace[i].FallbackOid, subtypeOid: metric.Namespace[i].SubtypeOid,
+					subtypeEncoding: metric.Namespace[i].SubtypeEncoding})
+				if err != nil {
+					log.WithFields(log.Fields{"namespace_part_configuration": metric.Namespace[i]}).Warn(err)
+					return nil, err
+				}
+				for _, value := range joined {
+					value = mapValue(metric.Namespace[i].ValueMap, value)
+					values[i] = append(values[i], ns.ReplaceNotAllowedCharsInNamespacePart(value))
+				}
+				break
+			}
+
+			parts, err := cache.read(handler, metric.Namespace[i].Oid, metric.Mode, metric.Namespace[i].CacheTTL, metric.Namespace[i].CacheInvalidateOid)
 			if err != nil {
-				return err
+				return nil, err
+			}
+
+			if len(parts) != len(results) && metric.Namespace[i].CacheTTL > 0 {
+				//cached namespace elements can be outdated if number of elements is different, so they are read again
+				cache.invalidate(metric.Namespace[i].Oid, metric.Mode)
+				parts, err = cache.read(handler, metric.Namespace[i].Oid, metric.Mode, metric.Namespace[i].CacheTTL, metric.Namespace[i].CacheInvalidateOid)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if notAvailable(parts, nil) && metric.Namespace[i].FallbackOid != "" {
+				parts, err = cache.read(handler, metric.Namespace[i].FallbackOid, metric.Mode, metric.Namespace[i].CacheTTL, metric.Namespace[i].CacheInvalidateOid)
+				if err != nil {
+					return nil, err
+				}
 			}
+
 			for _, part := range parts {
-				metricNamePart := ns.ReplaceNotAllowedCharsInNamespacePart(part.Variable.String())
-				metric.Namespace[i].Values = append(metric.Namespace[i].Values, metricNamePart)
+				metricNamePart := ns.ReplaceNotAllowedCharsInNamespacePart(mapValue(metric.Namespace[i].ValueMap, part.Variable.String()))
+				values[i] = append(values[i], metricNamePart)
 			}
 
 		case configReader.NsSourceIndex:
 			for _, r := range results {
 				oidParts := strings.Split(strings.Trim(r.Oid.String(), "."), ".")
 
-				if uint(len(oidParts)) <= metric.Namespace[i].OidPart {
-
+				value, err := decodeIndex(oidParts, metric.Namespace[i].OidPart, metric.Namespace[i].Encoding)
+				if err != nil {
 					logFields := log.Fields{
 						"namespace_part_configuration": metric.Namespace[i],
+						"oid":                          r.Oid.String(),
 						"oid_part":                     metric.Namespace[i].OidPart,
-						"number_of_oid_elements":       len(metric.Namespace[i].Values)}
-					err := fmt.Errorf("Incorrect value of `oid_part`  in configuration of namespace")
+						"encoding":                     metric.Namespace[i].Encoding}
 					log.WithFields(logFields).Warn(err)
-					return err
+					return nil, err
+				}
+				if metric.Namespace[i].ValueMap != nil {
+					value = ns.ReplaceNotAllowedCharsInNamespacePart(mapValue(metric.Namespace[i].ValueMap, value))
 				}
-				metric.Namespace[i].Values = append(metric.Namespace[i].Values, oidParts[metric.Namespace[i].OidPart])
+				values[i] = append(values[i], value)
 			}
 		}
 
-		if len(metric.Namespace[i].Values) != len(results) {
+		if len(values[i]) != len(results) {
 			logFields := log.Fields{
 				"namespace_part_configuration": metric.Namespace[i],
 				"number_of_results":            len(results),
-				"number_of_namespace_elements": len(metric.Namespace[i].Values)}
+				"number_of_namespace_elements": len(values[i])}
 			err := fmt.Errorf("Incorrect configuration of dynamic elements of namespace, number of namespace elements is not equal to number of results")
 			log.WithFields(logFields).Warn(err)
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return values, nil
 }
 
-//getMetricsConfig reads metrics parameters from configuration
+//getMetricsConfig reads metrics parameters from configuration, metrics are defined in setfile and/or in built-in profiles
 func getMetricsConfig(cfg plugin.Config) (configReader.Metrics, error) {
-	setFilePath, err := cfg.GetString(setFileConfigVar)
+	setFilePath, errSetFile := cfg.GetString(setFileConfigVar)
+	profileNames, errProfile := cfg.GetString(profileConfigVar)
+
+	//profiles which are selected by sysObjectID of discovered SNMP agents are loaded as well
+	discoveryProfileNames, err := configReader.GetDiscoveryProfileNames(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	configs, err := configReader.GetMetricsConfig(setFilePath)
-	if err != nil {
-		return nil, err
+	if errSetFile != nil && errProfile != nil && len(discoveryProfileNames) == 0 {
+		return nil, fmt.Errorf("Missing configuration of metrics, `%s` or `%s` must be set", setFileConfigVar, profileConfigVar)
+	}
+
+	configs := configReader.Metrics{}
+	if errSetFile == nil {
+		setFileConfigs, err := configReader.GetMetricsConfig(setFilePath)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, setFileConfigs...)
+	}
+
+	names := []string{}
+	if errProfile == nil {
+		names = strings.Split(profileNames, ",")
+	}
+	names = append(names, discoveryProfileNames...)
+
+	loaded := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if loaded[name] {
+			continue
+		}
+		loaded[name] = true
+
+		profile, err := profiles.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		profileConfigs, err := configReader.ParseMetricsConfig(profile)
+		if err != nil {
+			return nil, fmt.Errorf("Incorrect definition of metrics in profile (%s): %v", name, err)
+		}
+		for i := range profileConfigs {
+			profileConfigs[i].Profile = name
+		}
+		configs = append(configs, profileConfigs...)
 	}
 
 	return configs, nil
 }
 
+//getMatcher returns matcher of requested namespace, matcher is compiled once for namespace requested in task
+func (p *Plugin) getMatcher(namespace plugin.Namespace) namespaceMatcher {
+	p.mtxMatchers.Lock()
+	defer p.mtxMatchers.Unlock()
+
+	key := namespace.String()
+	matcher, ok := p.matchers[key]
+	if !ok {
+		matcher = newNamespaceMatcher(namespace.Strings())
+		p.matchers[key] = matcher
+	}
+	return matcher
+}
+
 //getMetricsToCollects gets configuration of metrics which are requested through task
-func getMetricsToCollect(namespace string, metrics map[string]configReader.Metric) (map[string]configReader.Metric, error) {
+func getMetricsToCollect(matcher namespaceMatcher, metrics map[string]configReader.Metric) (map[string]configReader.Metric, error) {
 	collectedMetrics := make(map[string]configReader.Metric)
 
 	// Filter out setfile based metrics by given namespace
 	for ns := range metrics {
-		matched, err := regexp.MatchString(strings.Replace(ns, "*", ".*", -1), namespace)
-		if err != nil {
-			return nil, err
-		}
-
-		if matched {
+		if matcher.match(splitNamespace(ns)) {
 			collectedMetrics[ns] = metrics[ns]
 		}
 	}
 	if len(collectedMetrics) == 0 {
-		return nil, fmt.Errorf("Metric namespace (`%s`) is not supported by this plugin", namespace)
+		return nil, fmt.Errorf("Metric namespace (`%s`) is not supported by this plugin", matcher.namespace)
 	}
 	return collectedMetrics, nil
 }
@@ -481,6 +1032,8 @@ func modifyNumericMetric(data interface{}, scale float64, shift float64) interfa
 		modifiedData = float64(data.(int32))*scale + shift
 	case int64:
 		modifiedData = float64(data.(int64))*scale + shift
+	case float64:
+		modifiedData = data.(float64)*scale + shift
 	default:
 		modifiedData = data
 	}
@@ -23,10 +23,19 @@ package collector
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/profiles"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
 	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
 	"github.com/k-sone/snmpgo"
 	. "github.com/smartystreets/goconvey/convey"
@@ -70,6 +79,8 @@ func (m *snmpMock) readElements(handler *snmpgo.SNMP, oid string, mode string) (
 	return varBinds, m.elementEntry.err
 }
 
+func (m *snmpMock) closeHandler(handler *snmpgo.SNMP) {}
+
 type snmpElementEntry struct {
 	element *snmpgo.VarBind
 	err     error
@@ -199,13 +210,24 @@ func TestNew(t *testing.T) {
 }
 
 func TestGetConfigPolicy(t *testing.T) {
-	plugin := New()
+	plg := New()
 
 	Convey("Getting config policy", t, func() {
-		So(func() { plugin.GetConfigPolicy() }, ShouldNotPanic)
-		configPolicy, err := plugin.GetConfigPolicy()
+		So(func() { plg.GetConfigPolicy() }, ShouldNotPanic)
+		configPolicy, err := plg.GetConfigPolicy()
 		So(err, ShouldBeNil)
 		So(configPolicy, ShouldNotBeNil)
+
+		Convey("when parameter has unknown type", func() {
+			err := addConfigRule(plugin.NewConfigPolicy(), configReader.ConfigParameter{Name: "timeout", Type: "float"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when default value is not one of possible options", func() {
+			err := addConfigRule(plugin.NewConfigPolicy(), configReader.ConfigParameter{Name: "network", Type: configReader.ParameterString,
+				Default: "sctp", Options: []interface{}{"udp", "tcp"}})
+			So(err, ShouldNotBeNil)
+		})
 	})
 }
 
@@ -361,9 +383,6 @@ func TestCollectMetrics(t *testing.T) {
 			}
 			So(func() { plg.CollectMetrics(mts) }, ShouldNotPanic)
 
-			//force initialization
-			plg.initialized = false
-
 			_, err := plg.CollectMetrics(mts)
 
 			So(err, ShouldNotBeNil)
@@ -402,7 +421,7 @@ func TestCollectMetrics(t *testing.T) {
 
 		Convey("when cannot create a new snmp handler", func() {
 			//clear connections map
-			snmpConnections = make(map[string]connection)
+			snmpConnections = make(map[string]*connection)
 
 			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[UNSUCCESSFULLY_CREATED_HANDLER],
 				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
@@ -503,237 +522,1993 @@ func TestCollectMetrics(t *testing.T) {
 	})
 }
 
-func TestConvertSnmpDataToMetric(t *testing.T) {
+func TestCollectMetricsWithCredentialSets(t *testing.T) {
+	Convey("Collecting metrics with credential sets", t, func() {
+		//clear connections map
+		snmpConnections = make(map[string]*connection)
 
-	Convey("Calling convertSnmpDataToMetric ", t, func() {
+		//create setfile
+		createMockFile(mockFileCont)
+		defer deleteMockFile()
 
-		Convey("with parsing error for Counter", func() {
-			_, serr := convertSnmpDataToMetric("12.3", "Counter")
-			So(serr, ShouldNotBeNil)
-		})
+		plg := New()
 
-		Convey("with parsing error for Counter64", func() {
-			_, serr := convertSnmpDataToMetric("12.3", "Counter64")
-			So(serr, ShouldNotBeNil)
-		})
+		//create host config
+		config := plugin.NewConfig()
+		config["snmp_version"] = "v2c"
+		config["snmp_agent_address"] = "127.0.0.2"
+		config["credential_sets"] = `[{"name": "old", "community": "public-old"}, {"community": "public-new"}]`
+		config[setFileConfigVar] = mockFilePath
 
-		Convey("with parsing error for Integer", func() {
-			_, serr := convertSnmpDataToMetric("12.3", "Integer")
-			So(serr, ShouldNotBeNil)
+		mts := []plugin.Metric{plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hostName"), Config: config}}
+
+		Convey("when the first credential set is accepted", func() {
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+
+			metrics, err := plg.CollectMetrics(mts)
+
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 1)
+			So(metrics[0].Tags[tagSnmpCredentialSet], ShouldEqual, "old")
+			So(metrics[0].Tags[tagSnmpAgentAddress], ShouldEqual, "127.0.0.2:161")
+			So(connectionAddresses(), ShouldContain, "127.0.0.2:161")
 		})
 
-		Convey("with parsing git derror for unsupported type", func() {
-			_, serr := convertSnmpDataToMetric("12.3", "Integer64")
-			So(serr, ShouldBeNil)
+		Convey("when none of credential sets is accepted", func() {
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_INCORRECT]}
+
+			metrics, err := plg.CollectMetrics(mts)
+
+			So(err, ShouldNotBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(connectionAddresses(), ShouldNotContain, "127.0.0.2:161")
 		})
 
+		Convey("when request fails after credential set is accepted", func() {
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+
+			_, err := plg.CollectMetrics(mts)
+			So(err, ShouldBeNil)
+
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_INCORRECT]}
+
+			metrics, err := plg.CollectMetrics(mts)
+
+			So(err, ShouldBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(connectionAddresses(), ShouldNotContain, "127.0.0.2:161")
+		})
 	})
 }
 
-func TestGetDynamicNamespaceElements(t *testing.T) {
-	Convey("Calling getDynamicNamespaceElements ", t, func() {
+type communityMock struct {
+	mtx         sync.Mutex
+	communities map[*snmpgo.SNMP]string
+}
 
-		//create SNMP agent config
-		agentConfig := make(map[string]interface{})
-		agentConfig["snmp_agent_name"] = "agent1"
-		agentConfig["snmp_agent_address"] = "127.0.0.1"
-		agentConfig["snmp_version"] = "v2c"
-		agentConfig["community"] = "public"
-		snmpAgentConfig, _ := configReader.GetSnmpAgentConfig(agentConfig)
+func (m *communityMock) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	handler := &snmpgo.SNMP{}
+	m.communities[handler] = hostConfig.Community
+	return handler, nil
+}
 
-		Convey("with incorrect value of `oid_part` parameter", func() {
+func (m *communityMock) readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	newOid, _ := snmpgo.NewOid(oid)
+	return []*snmpgo.VarBind{snmpgo.NewVarBind(newOid, snmpgo.NewOctetString([]byte(m.communities[handler])))}, nil
+}
 
-			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
-				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+func (m *communityMock) closeHandler(handler *snmpgo.SNMP) {}
 
-			//metric configuration
-			metricConfig := []configReader.Metric{configReader.Metric{
-				Oid:  ".1.3.6.1.2.1.1.2.0",
-				Mode: "table",
-				Namespace: []configReader.Namespace{
-					configReader.Namespace{Source: "string", String: "test1"},
-					configReader.Namespace{Source: "string", String: "test2"},
-					configReader.Namespace{Source: "index", OidPart: 102, Name: "name"},
-					configReader.Namespace{Source: "string", String: "value"},
-				},
-				Unit:        "unit",
-				Description: "description",
-			}}
+func TestCollectMetricsWithManyConfigs(t *testing.T) {
+	Convey("Collecting metrics with different configurations", t, func() {
+		snmpConnections = make(map[string]*connection)
+		snmp_ = &communityMock{communities: make(map[*snmpgo.SNMP]string)}
 
-			//create results
-			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+		createMockFile(mockFileCont)
+		defer deleteMockFile()
+
+		newConfig := func(community string) plugin.Config {
+			config := plugin.NewConfig()
+			config["snmp_version"] = "v2c"
+			config["snmp_agent_address"] = "127.0.0.1"
+			config["community"] = community
+			config[setFileConfigVar] = mockFilePath
+			return config
+		}
+
+		mts := []plugin.Metric{
+			plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hostName"), Config: newConfig("public")},
+			plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hrSystemNumUsers"), Config: newConfig("vendor")},
+			plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hrSystemProcesses"), Config: newConfig("public")},
+		}
+
+		Convey("each group of metrics is collected with its own configuration", func() {
+			metrics, err := New().CollectMetrics(mts)
 			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 3)
 
-			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
-			varBinds := []*snmpgo.VarBind{varBind}
+			data := map[string]interface{}{}
+			for _, m := range metrics {
+				data[m.Namespace.String()] = m.Data
+			}
+			So(data["/intel/snmp/hostName"], ShouldEqual, "public")
+			So(data["/intel/snmp/hrSystemNumUsers"], ShouldEqual, "vendor")
+			So(data["/intel/snmp/hrSystemProcesses"], ShouldEqual, "public")
 
-			handler, err := snmp_.newHandler(snmpAgentConfig)
+			//the same SNMP agent is used with different credentials
+			So(snmpConnections, ShouldHaveLength, 2)
+		})
+
+		Convey("metrics of other groups are returned if collection of one of groups fails", func() {
+			mts[1].Config["snmp_version"] = "v4"
+
+			metrics, err := New().CollectMetrics(mts)
 			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 2)
+		})
 
-			serr := getDynamicNamespaceElements(handler, varBinds, &metricConfig[0])
-			So(serr, ShouldNotBeNil)
+		Convey("error is returned if none of groups is collected", func() {
+			for i := range mts {
+				mts[i].Config["snmp_version"] = "v4"
+			}
 
+			_, err := New().CollectMetrics(mts)
+			So(err, ShouldNotBeNil)
 		})
+	})
+}
 
-		Convey("with incorrect configuration of dynamic elements of namespace, the number of results is not equal the number of dynamic elements of namespace ", func() {
+func TestResolveAgentAddress(t *testing.T) {
+	Convey("Resolving SNMP agent address", t, func() {
+		//clear connections map and resolved addresses
+		snmpConnections = make(map[string]*connection)
+		resolvedAddresses = make(map[string]resolvedAddress)
+		defer func() { lookupIP = net.LookupIP }()
+
+		lookups := 0
+		ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1")}
+		lookupIP = func(host string) ([]net.IP, error) {
+			lookups++
+			return ips, nil
+		}
 
-			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
-				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+		agentConfig := configReader.SnmpAgent{Network: "udp4", Address: "switch.example.com:161", DNSRefreshInterval: 60}
 
-			//metric configuration
-			metricConfig := []configReader.Metric{configReader.Metric{
-				Oid:  ".1.3.6.1.2.1.1.2.0",
-				Mode: "table",
-				Namespace: []configReader.Namespace{
-					configReader.Namespace{Source: "string", String: "test1"},
-					configReader.Namespace{Source: "string", String: "test2"},
-					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name"},
-					configReader.Namespace{Source: "string", String: "value"},
-				},
-				Unit:        "unit",
-				Description: "description",
-			}}
+		Convey("when address is IP address", func() {
+			address, err := resolveAgentAddress(configReader.SnmpAgent{Network: "udp6", Address: "[2001:db8::2]:161"})
+			So(err, ShouldBeNil)
+			So(address, ShouldEqual, "[2001:db8::2]:161")
+			So(lookups, ShouldEqual, 0)
+		})
 
-			//create results
-			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+		Convey("when host name is resolved", func() {
+			address, err := resolveAgentAddress(agentConfig)
 			So(err, ShouldBeNil)
+			So(address, ShouldEqual, "192.0.2.1:161")
 
-			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
-			varBinds := []*snmpgo.VarBind{varBind, varBind}
+			agentConfig.Network = "udp6"
+			address, err = resolveAgentAddress(agentConfig)
+			So(err, ShouldBeNil)
+			So(address, ShouldEqual, "[2001:db8::1]:161")
+			So(lookups, ShouldEqual, 2)
+		})
 
-			handler, err := snmp_.newHandler(snmpAgentConfig)
+		Convey("when resolved address is cached", func() {
+			_, err := resolveAgentAddress(agentConfig)
+			So(err, ShouldBeNil)
+			address, err := resolveAgentAddress(agentConfig)
 			So(err, ShouldBeNil)
+			So(address, ShouldEqual, "192.0.2.1:161")
+			So(lookups, ShouldEqual, 1)
+		})
 
-			serr := getDynamicNamespaceElements(handler, varBinds, &metricConfig[0])
-			So(serr, ShouldNotBeNil)
+		Convey("when host name cannot be resolved", func() {
+			lookupIP = func(host string) ([]net.IP, error) {
+				return nil, fmt.Errorf("no such host")
+			}
+			_, err := resolveAgentAddress(agentConfig)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when DNS is not available after host name was resolved", func() {
+			_, err := resolveAgentAddress(agentConfig)
+			So(err, ShouldBeNil)
 
+			resolvedAddresses[addressKey(agentConfig)] = resolvedAddress{address: "192.0.2.1:161", expires: time.Now()}
+			lookupIP = func(host string) ([]net.IP, error) {
+				return nil, fmt.Errorf("no such host")
+			}
+			address, err := resolveAgentAddress(agentConfig)
+			So(err, ShouldBeNil)
+			So(address, ShouldEqual, "192.0.2.1:161")
 		})
 
-		Convey("when SNMP request fails", func() {
+		Convey("when host name is resolved to different IP address", func() {
 			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
-				elementEntry: snmpElementTestTable[SNMP_ELEMENT_INCORRECT]}
-
-			//metric configuration
-			metricConfig := []configReader.Metric{configReader.Metric{
-				Oid:  ".1.3.6.1.2.1.1.2.0",
-				Mode: "table",
-				Namespace: []configReader.Namespace{
-					configReader.Namespace{Source: "string", String: "test1"},
-					configReader.Namespace{Source: "string", String: "test2"},
-					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name1"},
-					configReader.Namespace{Source: "string", String: "value"},
-				},
-				Unit:        "unit",
-				Description: "description",
-			}}
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
 
-			//create results
-			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+			conn, err := acquireConnection(agentConfig)
 			So(err, ShouldBeNil)
+			So(conn.address, ShouldEqual, "192.0.2.1:161")
+			releaseConnection(conn)
 
-			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
-			varBinds := []*snmpgo.VarBind{varBind}
+			resolvedAddresses[addressKey(agentConfig)] = resolvedAddress{address: "192.0.2.1:161", expires: time.Now()}
+			ips = []net.IP{net.ParseIP("192.0.2.2")}
 
-			handler, err := snmp_.newHandler(snmpAgentConfig)
+			conn, err = acquireConnection(agentConfig)
 			So(err, ShouldBeNil)
-
-			serr := getDynamicNamespaceElements(handler, varBinds, &metricConfig[0])
-			So(serr, ShouldNotBeNil)
+			So(conn.address, ShouldEqual, "192.0.2.2:161")
+			So(snmpConnections, ShouldHaveLength, 1)
+			So(snmpConnections, ShouldContainKey, connectionKey(agentConfig))
+			releaseConnection(conn)
 		})
 
-		Convey("when dynamic elements of namespace are successfully received", func() {
+		Convey("when DNS is slow connections with other SNMP agents are not blocked", func() {
 			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
 				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
 
-			//metric configuration
-			metricConfig := []configReader.Metric{configReader.Metric{
-				Oid:  ".1.3.6.1.2.1.1.2.0",
-				Mode: "table",
-				Namespace: []configReader.Namespace{
-					configReader.Namespace{Source: "string", String: "test1"},
-					configReader.Namespace{Source: "string", String: "test2"},
-					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name1"},
-					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name2"},
-					configReader.Namespace{Source: "index", OidPart: 3, Name: "name3"},
-					configReader.Namespace{Source: "string", String: "value"},
-				},
-				Unit:        "unit",
-				Description: "description",
-			}}
-
-			//create results
-			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
-			So(err, ShouldBeNil)
+			unblock := make(chan struct{})
+			lookupIP = func(host string) ([]net.IP, error) {
+				<-unblock
+				return ips, nil
+			}
 
-			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
-			varBinds := []*snmpgo.VarBind{varBind}
+			resolved := make(chan error)
+			go func() {
+				conn, err := acquireConnection(agentConfig)
+				if err == nil {
+					releaseConnection(conn)
+				}
+				resolved <- err
+			}()
+
+			acquired := make(chan error)
+			go func() {
+				conn, err := acquireConnection(configReader.SnmpAgent{Network: "udp4", Address: "192.0.2.10:161"})
+				if err == nil {
+					releaseConnection(conn)
+				}
+				acquired <- err
+			}()
 
-			handler, err := snmp_.newHandler(snmpAgentConfig)
-			So(err, ShouldBeNil)
+			select {
+			case err := <-acquired:
+				So(err, ShouldBeNil)
+			case <-time.After(5 * time.Second):
+				So("connection with SNMP agent was blocked by DNS lookup", ShouldBeEmpty)
+			}
 
-			serr := getDynamicNamespaceElements(handler, varBinds, &metricConfig[0])
-			So(serr, ShouldBeNil)
+			close(unblock)
+			So(<-resolved, ShouldBeNil)
 		})
 	})
 }
 
-func TestGetMetricsToCollect(t *testing.T) {
-	Convey("Calling getMetricsToCollect ", t, func() {
-		metricConfig := configReader.Metric{
-			Oid:  ".1.3.6.1.2.1.1.2.0",
-			Mode: "table",
-			Namespace: []configReader.Namespace{
-				configReader.Namespace{Source: "string", String: "test1"},
-				configReader.Namespace{Source: "string", String: "test2"},
-				configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name1"},
-				configReader.Namespace{Source: "string", String: "value"},
-			},
-			Unit:        "unit",
-			Description: "description",
-		}
+func TestConvertSnmpDataToMetric(t *testing.T) {
 
-		metricsConfigs := map[string]configReader.Metric{
-			"/intel/snmp/test1/test2/*/value": metricConfig,
-			"/intel/snmp/test1/test3/*/value": metricConfig,
-		}
+	Convey("Calling convertSnmpDataToMetric ", t, func() {
 
-		Convey("with correct arguments", func() {
+		Convey("with parsing error for Counter", func() {
+			_, serr := convertSnmpDataToMetric("12.3", "Counter")
+			So(serr, ShouldNotBeNil)
+		})
 
-			collectedMetrics, serr := getMetricsToCollect("/intel/snmp/test1/test2/*/value", metricsConfigs)
+		Convey("with parsing error for Counter64", func() {
+			_, serr := convertSnmpDataToMetric("12.3", "Counter64")
+			So(serr, ShouldNotBeNil)
+		})
 
-			So(serr, ShouldBeNil)
-			So(len(collectedMetrics), ShouldEqual, 1)
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Oid, ShouldEqual, ".1.3.6.1.2.1.1.2.0")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Mode, ShouldEqual, "table")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Unit, ShouldEqual, "unit")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Description, ShouldEqual, "description")
-			So(len(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace), ShouldEqual, 4)
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[0].String, ShouldEqual, "test1")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[0].Source, ShouldEqual, "string")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[1].String, ShouldEqual, "test2")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[1].Source, ShouldEqual, "string")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[2].Source, ShouldEqual, "snmp")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[2].Oid, ShouldEqual, ".1.3.6.1.2.1.1.9.1.3")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[3].String, ShouldEqual, "value")
-			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[3].Source, ShouldEqual, "string")
+		Convey("with parsing error for Integer", func() {
+			_, serr := convertSnmpDataToMetric("12.3", "Integer")
+			So(serr, ShouldNotBeNil)
 		})
 
-		Convey("with incorrect regex expression", func() {
+		Convey("with parsing git derror for unsupported type", func() {
+			_, serr := convertSnmpDataToMetric("12.3", "Integer64")
+			So(serr, ShouldBeNil)
+		})
 
-			collectedMetrics, serr := getMetricsToCollect("a(b", metricsConfigs)
+	})
+}
 
-			So(serr, ShouldNotBeNil)
-			So(len(collectedMetrics), ShouldEqual, 0)
+func TestDecodeIndex(t *testing.T) {
+	Convey("Calling decodeIndex", t, func() {
+
+		Convey("with correct indexes", func() {
+			indexes := []struct {
+				oid      string
+				start    uint
+				encoding string
+				value    string
+			}{
+				{"1.3.6.1.2.1.2.2.1.10.7", 10, "", "7"},
+				{"1.3.6.1.2.1.4.22.1.2.7.10.0.0.1", 10, "integer", "7"},
+				{"1.3.6.1.2.1.4.22.1.2.7.10.0.0.1", 11, "ipv4", "10.0.0.1"},
+				{"1.3.6.1.2.1.4.35.1.4.7.2.16.32.1.13.184.0.0.0.0.0.0.0.0.0.0.0.1", 13, "ipv6", "2001:db8::1"},
+				{"1.3.6.1.2.1.4.35.1.4.7.2.16.32.1.13.184.0.0.0.0.0.0.0.0.0.0.0.1", 11, "inet_address", "2001:db8::1"},
+				{"1.3.6.1.2.1.4.35.1.4.7.1.4.10.0.0.1", 11, "inet_address", "10.0.0.1"},
+				{"1.3.6.1.2.1.17.4.3.1.2.0.17.34.51.68.85", 11, "mac", "00:11:22:33:44:55"},
+				{"1.3.6.1.4.1.1.1.4.101.116.104.48.5", 8, "string", "eth0"},
+				{"1.3.6.1.4.1.1.1.101.116.104.48", 8, "implied_string", "eth0"},
+				{"1.3.6.1.4.1.1.1.2.1.255", 8, "implied_string", "02:01:ff"},
+				{"1.3.6.1.4.1.1.1.3.1.3.6.5", 8, "oid", "1.3.6"},
+				{"1.3.6.1.4.1.1.1.1.3.6", 8, "implied_oid", "1.3.6"},
+			}
+			for _, index := range indexes {
+				value, err := decodeIndex(strings.Split(index.oid, "."), index.start, index.encoding)
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, index.value)
+			}
+		})
 
+		Convey("with incorrect indexes", func() {
+			indexes := []struct {
+				oid      string
+				start    uint
+				encoding string
+			}{
+				{"1.3.6.1.2.1.2.2.1.10.7", 11, ""},
+				{"1.3.6.1.2.1.4.22.1.2.7.10.0.0", 11, "ipv4"},
+				{"1.3.6.1.2.1.4.22.1.2.7.10.0.0.256", 11, "ipv4"},
+				{"1.3.6.1.2.1.4.35.1.4.7.1.16.10.0.0.1", 11, "inet_address"},
+				{"1.3.6.1.4.1.1.1.5.101.116.104.48", 8, "string"},
+				{"1.3.6.1.4.1.1.1.5.1.3", 8, "oid"},
+				{"1.3.6.1.4.1.1.1.5", 8, "ipv5"},
+			}
+			for _, index := range indexes {
+				_, err := decodeIndex(strings.Split(index.oid, "."), index.start, index.encoding)
+				So(err, ShouldNotBeNil)
+			}
 		})
 	})
 }
 
-func createMockFile(fileCont []byte) {
-	deleteMockFile()
+type tableMock struct {
+	tables map[string][]*snmpgo.VarBind
+	reads  map[string]int
+}
 
-	f, _ := os.Create(mockFilePath)
-	f.Write(fileCont)
+func (m *tableMock) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
+	return &snmpgo.SNMP{}, nil
+}
+
+func (m *tableMock) readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	m.reads[oid]++
+	varBinds, ok := m.tables[oid]
+	if !ok {
+		return nil, fmt.Errorf("Error - OID %s not found", oid)
+	}
+	return varBinds, nil
+}
+
+func (m *tableMock) closeHandler(handler *snmpgo.SNMP) {}
+
+func newVarBinds(oid string, values map[string]snmpgo.Variable) []*snmpgo.VarBind {
+	varBinds := []*snmpgo.VarBind{}
+	for index, value := range values {
+		newOid, _ := snmpgo.NewOid(oid + index)
+		varBinds = append(varBinds, snmpgo.NewVarBind(newOid, value))
+	}
+	return varBinds
+}
+
+func TestResolveJoin(t *testing.T) {
+	Convey("Calling resolveJoin", t, func() {
+		mock := &tableMock{tables: map[string][]*snmpgo.VarBind{
+			//ifDescr
+			".1.3.6.1.2.1.2.2.1.2": newVarBinds(".1.3.6.1.2.1.2.2.1.2", map[string]snmpgo.Variable{
+				".1": snmpgo.NewOctetString([]byte("lo")), ".2": snmpgo.NewOctetString([]byte("eth0"))}),
+			//ipAdEntIfIndex
+			".1.3.6.1.2.1.4.20.1.2": newVarBinds(".1.3.6.1.2.1.4.20.1.2", map[string]snmpgo.Variable{
+				".127.0.0.1": snmpgo.NewInteger(1), ".10.0.0.1": snmpgo.NewInteger(2)}),
+			//sysName
+			".1.3.6.1.2.1.1.5.0": newVarBinds(".1.3.6.1.2.1.1.5.0", map[string]snmpgo.Variable{
+				"": snmpgo.NewOctetString([]byte("switch"))}),
+		}, reads: map[string]int{}}
+		snmp_ = mock
+		cache := newTableCache(nil)
+
+		Convey("with join using shared index", func() {
+			//ifHCInOctets in ifXTable augments ifTable
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".2": snmpgo.NewCounter64(10)})
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.2.2.1.2", join: "index"})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"eth0"})
+
+			//column is read from cache
+			_, err = resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.2.2.1.2", join: "index"})
+			So(err, ShouldBeNil)
+			So(mock.reads[".1.3.6.1.2.1.2.2.1.2"], ShouldEqual, 1)
+		})
+
+		Convey("with join using foreign index which is a part of composite index", func() {
+			//ipNetToMediaPhysAddress is indexed by ifIndex and IP address
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.4.22.1.2", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".2.10.0.0.2": snmpgo.NewOctetString([]byte{0, 1, 2, 3, 4, 5})})
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.2.2.1.2", join: "index", oidPart: 10})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"eth0"})
+		})
+
+		Convey("with join using pointer column", func() {
+			//ipAdEntNetMask is indexed by IP address, ipAdEntIfIndex points to ifTable
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.4.20.1", Mode: "walk"}
+			results := newVarBinds(".1.3.6.1.2.1.4.20.1.3", map[string]snmpgo.Variable{".10.0.0.1": snmpgo.NewIpaddress(255, 0, 0, 0)})
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.2.2.1.2", join: "pointer", indexOid: ".1.3.6.1.2.1.4.20.1.2"})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"eth0"})
+		})
+
+		Convey("with scalar", func() {
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.2.2.1.10", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".1": snmpgo.NewCounter32(1), ".2": snmpgo.NewCounter32(2)})
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.1.5.0"})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"switch", "switch"})
+		})
+
+		Convey("with missing row in joined column", func() {
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".3": snmpgo.NewCounter64(10)})
+
+			_, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.2.2.1.2", join: "index"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("with metric in single mode without `oid_part`", func() {
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6.2", Mode: "single"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{"": snmpgo.NewCounter64(10)})
+
+			_, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.2.2.1.2", join: "index"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("with tags", func() {
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table", Tags: []configReader.Tag{
+				configReader.Tag{Name: "ifDescr", Oid: ".1.3.6.1.2.1.2.2.1.2", Join: "index"},
+				configReader.Tag{Name: "sysName", Oid: ".1.3.6.1.2.1.1.5.0"},
+			}}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".1": snmpgo.NewCounter64(10)})
+
+			tags := getTags(nil, cache, results, metric)
+			So(tags, ShouldResemble, []map[string]string{map[string]string{"ifDescr": "lo", "sysName": "switch"}})
+		})
+
+		Convey("with tag which cannot be read", func() {
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table", Tags: []configReader.Tag{
+				configReader.Tag{Name: "ifDescr", Oid: ".1.3.6.1.2.1.2.2.1.2", Join: "index"},
+				configReader.Tag{Name: "ifAlias", Oid: ".1.3.6.1.2.1.31.1.1.1.18", Join: "index"},
+			}}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".1": snmpgo.NewCounter64(10)})
+
+			tags := getTags(nil, cache, results, metric)
+			So(tags, ShouldResemble, []map[string]string{map[string]string{"ifDescr": "lo"}})
+		})
+
+		Convey("with fallback of empty value in joined column", func() {
+			//ifName of the first interface is empty, ifDescr is used instead
+			mock.tables[".1.3.6.1.2.1.31.1.1.1.1"] = newVarBinds(".1.3.6.1.2.1.31.1.1.1.1", map[string]snmpgo.Variable{
+				".1": snmpgo.NewOctetString([]byte("")), ".2": snmpgo.NewOctetString([]byte("Gi0/1"))})
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".1": snmpgo.NewCounter64(10)})
+			results = append(results, newVarBinds(metric.Oid, map[string]snmpgo.Variable{".2": snmpgo.NewCounter64(10)})...)
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.31.1.1.1.1", join: "index", fallbackOid: ".1.3.6.1.2.1.2.2.1.2"})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"lo", "Gi0/1"})
+		})
+
+		Convey("with subtype of joined column", func() {
+			//lldpRemChassisId decoded according to lldpRemChassisIdSubtype
+			mock.tables[".1.0.8802.1.1.2.1.4.1.1.4"] = newVarBinds(".1.0.8802.1.1.2.1.4.1.1.4", map[string]snmpgo.Variable{
+				".0.1.1": snmpgo.NewInteger(4), ".0.2.1": snmpgo.NewInteger(7)})
+			mock.tables[".1.0.8802.1.1.2.1.4.1.1.5"] = newVarBinds(".1.0.8802.1.1.2.1.4.1.1.5", map[string]snmpgo.Variable{
+				".0.1.1": snmpgo.NewOctetString([]byte{0, 1, 2, 3, 4, 5}), ".0.2.1": snmpgo.NewOctetString([]byte("switch"))})
+			metric := &configReader.Metric{Oid: ".1.0.8802.1.1.2.1.4.1.1.9", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".0.1.1": snmpgo.NewOctetString([]byte("a"))})
+			results = append(results, newVarBinds(metric.Oid, map[string]snmpgo.Variable{".0.2.1": snmpgo.NewOctetString([]byte("b"))})...)
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.0.8802.1.1.2.1.4.1.1.5", join: "index",
+				subtypeOid: ".1.0.8802.1.1.2.1.4.1.1.4", subtypeEncoding: map[string]string{"4": "mac", "7": "string"}})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"00:01:02:03:04:05", "switch"})
+		})
+
+		Convey("with fallback of joined column", func() {
+			//ifName is not available, ifDescr is used instead
+			mock.tables[".1.3.6.1.2.1.31.1.1.1.1"] = []*snmpgo.VarBind{}
+			metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table"}
+			results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".2": snmpgo.NewCounter64(10)})
+
+			values, err := resolveJoin(nil, cache, results, metric, join{oid: ".1.3.6.1.2.1.31.1.1.1.1", join: "index", fallbackOid: ".1.3.6.1.2.1.2.2.1.2"})
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, []string{"eth0"})
+		})
+	})
+}
+
+func TestReadMetric(t *testing.T) {
+	Convey("Reading metric with fallback OID", t, func() {
+		ifHCInOctets := ".1.3.6.1.2.1.31.1.1.1.6"
+		ifInOctets := ".1.3.6.1.2.1.2.2.1.10"
+		mock := &tableMock{tables: map[string][]*snmpgo.VarBind{
+			ifInOctets: newVarBinds(ifInOctets, map[string]snmpgo.Variable{".1": snmpgo.NewCounter32(10)}),
+		}, reads: map[string]int{}}
+		snmp_ = mock
+
+		Convey("when OID is available", func() {
+			mock.tables[ifHCInOctets] = newVarBinds(ifHCInOctets, map[string]snmpgo.Variable{".1": snmpgo.NewCounter64(20)})
+			cfg := &configReader.Metric{Oid: ifHCInOctets, FallbackOid: ifInOctets, Mode: "table"}
+
+			results, err := readMetric(nil, cfg)
+			So(err, ShouldBeNil)
+			So(results[0].Variable.String(), ShouldEqual, "20")
+			So(cfg.Oid, ShouldEqual, ifHCInOctets)
+		})
+
+		Convey("when OID is not available", func() {
+			mock.tables[ifHCInOctets] = newVarBinds(ifHCInOctets, map[string]snmpgo.Variable{".1": snmpgo.NewNoSucheObject()})
+			cfg := &configReader.Metric{Oid: ifHCInOctets, FallbackOid: ifInOctets, Mode: "table"}
+
+			results, err := readMetric(nil, cfg)
+			So(err, ShouldBeNil)
+			So(results[0].Variable.String(), ShouldEqual, "10")
+			So(cfg.Oid, ShouldEqual, ifInOctets)
+		})
+
+		Convey("when table is empty", func() {
+			mock.tables[ifHCInOctets] = []*snmpgo.VarBind{}
+			cfg := &configReader.Metric{Oid: ifHCInOctets, FallbackOid: ifInOctets, Mode: "table"}
+
+			results, err := readMetric(nil, cfg)
+			So(err, ShouldBeNil)
+			So(results[0].Variable.String(), ShouldEqual, "10")
+		})
+
+		Convey("when fallback OID is not set", func() {
+			mock.tables[ifHCInOctets] = []*snmpgo.VarBind{}
+			cfg := &configReader.Metric{Oid: ifHCInOctets, Mode: "table"}
+
+			results, err := readMetric(nil, cfg)
+			So(err, ShouldBeNil)
+			So(results, ShouldBeEmpty)
+			So(mock.reads[ifInOctets], ShouldEqual, 0)
+		})
+	})
+}
+
+func TestReadOperations(t *testing.T) {
+	Convey("Reading divisors with fallback column", t, func() {
+		ifHighSpeed := ".1.3.6.1.2.1.31.1.1.1.15"
+		ifSpeed := ".1.3.6.1.2.1.2.2.1.5"
+		mock := &tableMock{tables: map[string][]*snmpgo.VarBind{
+			ifSpeed: newVarBinds(ifSpeed, map[string]snmpgo.Variable{".1": snmpgo.NewGauge32(10000000), ".2": snmpgo.NewGauge32(100000)}),
+		}, reads: map[string]int{}}
+		snmp_ = mock
+		cfg := &configReader.Metric{Oid: ".1.3.6.1.2.1.2.2.1.10", Mode: "table", DivideByOid: ifHighSpeed, DivideByFallbackOid: ifSpeed, DivideByFallbackScale: 0.000001}
+		results := newVarBinds(cfg.Oid, map[string]snmpgo.Variable{".1": snmpgo.NewCounter32(1)})
+
+		Convey("when divisor column is available", func() {
+			mock.tables[ifHighSpeed] = newVarBinds(ifHighSpeed, map[string]snmpgo.Variable{".1": snmpgo.NewGauge32(1000)})
+
+			operations, err := readOperations(nil, newTableCache(nil), results, cfg)
+			So(err, ShouldBeNil)
+			So(operations, ShouldHaveLength, 1)
+			So(operations[0].operands, ShouldResemble, []string{"1000"})
+		})
+
+		Convey("when divisor column is not available", func() {
+			operations, err := readOperations(nil, newTableCache(nil), results, cfg)
+			So(err, ShouldBeNil)
+			So(operations, ShouldHaveLength, 1)
+			So(operations[0].operands, ShouldResemble, []string{"10"})
+		})
+
+		Convey("when divisor is 0", func() {
+			//ifHighSpeed is 0 for interfaces slower than 500 kbit/s
+			results := newVarBinds(cfg.Oid, map[string]snmpgo.Variable{".2": snmpgo.NewCounter32(1)})
+			mock.tables[ifHighSpeed] = newVarBinds(ifHighSpeed, map[string]snmpgo.Variable{".2": snmpgo.NewGauge32(0)})
+
+			operations, err := readOperations(nil, newTableCache(nil), results, cfg)
+			So(err, ShouldBeNil)
+			divisor, err := strconv.ParseFloat(operations[0].operands[0], 64)
+			So(err, ShouldBeNil)
+			So(divisor, ShouldAlmostEqual, 0.1)
+		})
+
+		Convey("when fallback column is not set", func() {
+			cfg := &configReader.Metric{Oid: ".1.3.6.1.2.1.2.2.1.10", Mode: "table", DivideByOid: ifHighSpeed}
+
+			_, err := readOperations(nil, newTableCache(nil), results, cfg)
+			So(err, ShouldNotBeNil)
+			So(mock.reads[ifSpeed], ShouldEqual, 0)
+		})
+	})
+}
+
+func TestMetricStateRate(t *testing.T) {
+	Convey("Calculating rate of metric", t, func() {
+		state := newMetricState()
+		now := time.Now()
+
+		Convey("when previous value is not available", func() {
+			_, ok := state.rate("key", uint64(10), "Counter64", now)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("when counter increases", func() {
+			state.rate("key", uint64(100), "Counter64", now)
+			rate, ok := state.rate("key", uint64(300), "Counter64", now.Add(10*time.Second))
+			So(ok, ShouldBeTrue)
+			So(rate, ShouldEqual, 20)
+		})
+
+		Convey("when 32-bit counter wraps", func() {
+			state.rate("key", uint64(math.MaxUint32-9), "Counter32", now)
+			rate, ok := state.rate("key", uint64(10), "Counter32", now.Add(10*time.Second))
+			So(ok, ShouldBeTrue)
+			So(rate, ShouldEqual, 2)
+		})
+
+		Convey("when 64-bit counter decreases", func() {
+			state.rate("key", uint64(math.MaxUint64-9), "Counter64", now)
+			_, ok := state.rate("key", uint64(10), "Counter64", now.Add(10*time.Second))
+			So(ok, ShouldBeFalse)
+
+			//the next rate is calculated from the value after reset
+			rate, ok := state.rate("key", uint64(110), "Counter64", now.Add(20*time.Second))
+			So(ok, ShouldBeTrue)
+			So(rate, ShouldEqual, 10)
+		})
+
+		Convey("when SNMP agent is restarted", func() {
+			state.checkSysUpTime(1000)
+			state.rate("key", uint64(math.MaxUint32-9), "Counter32", now)
+
+			state.checkSysUpTime(100)
+			_, ok := state.rate("key", uint64(10), "Counter32", now.Add(10*time.Second))
+			So(ok, ShouldBeFalse)
+
+			state.checkSysUpTime(1100)
+			rate, ok := state.rate("key", uint64(30), "Counter32", now.Add(20*time.Second))
+			So(ok, ShouldBeTrue)
+			So(rate, ShouldEqual, 2)
+		})
+
+		Convey("when gauge decreases", func() {
+			state.rate("key", int64(100), "Integer", now)
+			rate, ok := state.rate("key", int64(50), "Integer", now.Add(10*time.Second))
+			So(ok, ShouldBeTrue)
+			So(rate, ShouldEqual, -5)
+		})
+
+		Convey("when type of value changes", func() {
+			state.rate("key", uint64(100), "Counter32", now)
+			_, ok := state.rate("key", uint64(300), "Counter64", now.Add(10*time.Second))
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestOperands(t *testing.T) {
+	Convey("Modifying metric value by value read from SNMP agent", t, func() {
+		Convey("when value is multiplied", func() {
+			value, err := multiply(uint64(100), "4096")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 409600)
+		})
+
+		Convey("when value is divided", func() {
+			value, err := divide(int64(50), "200")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 0.25)
+		})
+
+		Convey("when divisor is 0", func() {
+			_, err := divide(uint64(50), "0")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when divisor is special negative value", func() {
+			_, err := divide(int64(50), "-2")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when value is not numeric", func() {
+			_, err := multiply("eth0", "2")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when operand is not numeric", func() {
+			_, err := multiply(uint64(1), "eth0")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when sensor value is scaled", func() {
+			//milli
+			value, err := applyDataScale(int64(12500), "8")
+			So(err, ShouldBeNil)
+			So(value, ShouldAlmostEqual, 12.5)
+
+			//kilo
+			value, err = applyDataScale(uint64(3), "10")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 3000)
+
+			_, err = applyDataScale(uint64(3), "18")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when precision of sensor value is applied", func() {
+			value, err := applyPrecision(int64(425), "1")
+			So(err, ShouldBeNil)
+			So(value, ShouldAlmostEqual, 42.5)
+
+			value, err = applyPrecision(int64(4), "-2")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, 400)
+
+			_, err = applyPrecision(int64(4), "10")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when operations are applied in order", func() {
+			operations := []operation{
+				operation{operands: []string{"9", "8"}, apply: applyDataScale},
+				operation{operands: []string{"1", "2"}, apply: applyPrecision},
+			}
+			value, err := applyOperations(int64(425), operations, 1)
+			So(err, ShouldBeNil)
+			So(value, ShouldAlmostEqual, 0.00425)
+
+			value, err = applyOperations(int64(425), nil, 0)
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, int64(425))
+		})
+	})
+}
+
+func TestDecodeValue(t *testing.T) {
+	Convey("Decoding values according to subtype", t, func() {
+		mac := snmpgo.NewOctetString([]byte{0x00, 0x1b, 0x21, 0x3c, 0x4d, 0x5e})
+
+		So(decodeValue(mac, "mac"), ShouldEqual, "00:1b:21:3c:4d:5e")
+		So(decodeValue(mac, "hex"), ShouldEqual, "00:1b:21:3c:4d:5e")
+		So(decodeValue(snmpgo.NewOctetString([]byte{10, 0, 0, 1}), "ip"), ShouldEqual, "10.0.0.1")
+		So(decodeValue(snmpgo.NewOctetString([]byte{1, 192, 168, 0, 1}), "network_address"), ShouldEqual, "192.168.0.1")
+		So(decodeValue(snmpgo.NewOctetString(append([]byte{2}, net.ParseIP("fe80::1")...)), "network_address"), ShouldEqual, "fe80::1")
+		So(decodeValue(snmpgo.NewOctetString([]byte("Gi0/1")), "string"), ShouldEqual, "Gi0/1")
+		So(decodeValue(snmpgo.NewOctetString([]byte{0x01, 0x02}), "string"), ShouldEqual, "01:02")
+		So(decodeValue(snmpgo.NewOctetString([]byte{0x01, 0x02}), "mac"), ShouldEqual, "01:02")
+		So(decodeValue(snmpgo.NewOctetString([]byte("Gi0/1")), ""), ShouldEqual, "Gi0/1")
+		So(decodeValue(snmpgo.NewInteger(5), "mac"), ShouldEqual, "5")
+	})
+}
+
+func TestSkipValue(t *testing.T) {
+	Convey("Skipping special values", t, func() {
+		skipValues := []string{"-2", "-3"}
+
+		So(skipValue(skipValues, "-3"), ShouldBeTrue)
+		So(skipValue(skipValues, "-1"), ShouldBeFalse)
+		So(skipValue(skipValues, "75"), ShouldBeFalse)
+		So(skipValue(nil, "-3"), ShouldBeFalse)
+	})
+}
+
+func TestMapValue(t *testing.T) {
+	Convey("Mapping metric values to labels", t, func() {
+		valueMap := map[string]string{"6": "established"}
+
+		So(mapMetricValue(valueMap, int64(6)), ShouldEqual, "established")
+		So(mapMetricValue(valueMap, uint64(6)), ShouldEqual, "established")
+		So(mapMetricValue(valueMap, int64(1)), ShouldEqual, int64(1))
+		So(mapMetricValue(nil, int64(1)), ShouldEqual, int64(1))
+	})
+
+	Convey("Mapping values to labels", t, func() {
+		valueMap := map[string]string{"1": "up", ".1.3.6.1.2.1.25.2.1.4": "fixed_disk"}
+
+		So(mapValue(valueMap, "1"), ShouldEqual, "up")
+		So(mapValue(valueMap, "2"), ShouldEqual, "2")
+		So(mapValue(valueMap, "1.3.6.1.2.1.25.2.1.4"), ShouldEqual, "fixed_disk")
+		So(mapValue(valueMap, ".1.3.6.1.2.1.25.2.1.4"), ShouldEqual, "fixed_disk")
+		So(mapValue(nil, "1"), ShouldEqual, "1")
+	})
+}
+
+func TestMetricStateChange(t *testing.T) {
+	Convey("Detecting change of metric value", t, func() {
+		state := newMetricState()
+
+		Convey("when previous value is not available", func() {
+			_, changed := state.change("key", int64(6))
+			So(changed, ShouldBeFalse)
+		})
+
+		Convey("when value does not change", func() {
+			state.change("key", int64(6))
+			_, changed := state.change("key", int64(6))
+			So(changed, ShouldBeFalse)
+		})
+
+		Convey("when value changes", func() {
+			state.change("key", int64(6))
+			previous, changed := state.change("key", int64(1))
+			So(changed, ShouldBeTrue)
+			So(previous, ShouldEqual, int64(6))
+
+			_, changed = state.change("key", int64(1))
+			So(changed, ShouldBeFalse)
+		})
+
+		Convey("when values of different metrics are checked", func() {
+			state.change("peer1", int64(6))
+			_, changed := state.change("peer2", int64(1))
+			So(changed, ShouldBeFalse)
+		})
+	})
+}
+
+func TestProfiles(t *testing.T) {
+	Convey("Built-in profiles", t, func() {
+		Convey("are correct", func() {
+			for _, name := range profiles.Names() {
+				content, err := profiles.Get(name)
+				So(err, ShouldBeNil)
+				_, err = configReader.ParseMetricsConfig(content)
+				So(err, ShouldBeNil)
+			}
+		})
+
+		Convey("are exposed as metric types", func() {
+			config := plugin.NewConfig()
+			config[profileConfigVar] = "if-mib, host, entity, lldp, cdp, routing, ups, printer"
+
+			mts, err := New().GetMetricTypes(config)
+			So(err, ShouldBeNil)
+			So(mts, ShouldNotBeEmpty)
+		})
+
+		Convey("when profile is unknown", func() {
+			config := plugin.NewConfig()
+			config[profileConfigVar] = "if-mib, unknown"
+
+			_, err := New().GetMetricTypes(config)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+type agentsMock struct {
+	mtx      sync.Mutex
+	agents   map[string]map[string]snmpgo.Variable
+	handlers map[*snmpgo.SNMP]string
+}
+
+func (m *agentsMock) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	handler := &snmpgo.SNMP{}
+	m.handlers[handler] = hostConfig.Address
+	return handler, nil
+}
+
+//readElements reads OIDs of SNMP agent with requests sent by snmp.Walk, so modes are handled as by SNMP layer of plugin
+func (m *agentsMock) readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	return snmp.Walk(func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+		return m.sendRequest(handler, pduType, oid)
+	}, oid, mode)
+}
+
+//sendRequest serves single request from OIDs of SNMP agent, GETNEXT returns the first OID which follows requested one
+func (m *agentsMock) sendRequest(handler *snmpgo.SNMP, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	agent, ok := m.agents[m.handlers[handler]]
+	if !ok {
+		return nil, &snmp.TimeoutError{Cause: fmt.Errorf("request timeout")}
+	}
+
+	requested, err := snmpgo.NewOid(oid)
+	if err != nil {
+		return nil, err
+	}
+	pdu := snmpgo.NewPdu(snmpgo.V2c, snmpgo.GetResponse)
+	if pduType == snmpgo.GetRequest {
+		if value, ok := agent[oid]; ok {
+			pdu.AppendVarBind(requested, value)
+		} else {
+			pdu.AppendVarBind(requested, snmpgo.NewNoSucheObject())
+		}
+		return pdu, nil
+	}
+
+	var next *snmpgo.Oid
+	for key := range agent {
+		candidate, _ := snmpgo.NewOid(key)
+		if candidate.Compare(requested) > 0 && (next == nil || candidate.Compare(next) < 0) {
+			next = candidate
+		}
+	}
+	if next == nil {
+		pdu.AppendVarBind(requested, snmpgo.NewEndOfMibView())
+		return pdu, nil
+	}
+	pdu.AppendVarBind(next, agent["."+next.String()])
+	return pdu, nil
+}
+
+func (m *agentsMock) closeHandler(handler *snmpgo.SNMP) {}
+
+//collectFromAgent collects metrics matching namespace from SNMP agent served by agentsMock, metrics are returned by namespace
+func collectFromAgent(agent map[string]snmpgo.Variable, config plugin.Config, namespace string) (map[string]plugin.Metric, error) {
+	snmpConnections = make(map[string]*connection)
+	snmp_ = &agentsMock{handlers: make(map[*snmpgo.SNMP]string), agents: map[string]map[string]snmpgo.Variable{"127.0.0.1:161": agent}}
+
+	//sysUpTime is read when cached namespace elements or tags are used
+	if _, ok := agent[sysUpTimeOid]; !ok {
+		agent[sysUpTimeOid] = snmpgo.NewTimeTicks(100)
+	}
+
+	config["snmp_agent_address"] = "127.0.0.1"
+	config["snmp_version"] = "v2c"
+	config["community"] = "public"
+
+	mts, err := New().CollectMetrics([]plugin.Metric{{Namespace: plugin.NewNamespace(splitNamespace(namespace)...), Config: config}})
+	metrics := map[string]plugin.Metric{}
+	for _, mt := range mts {
+		metrics[mt.Namespace.String()] = mt
+	}
+	return metrics, err
+}
+
+func newAgent(sysName string, sysObjectID string) map[string]snmpgo.Variable {
+	return map[string]snmpgo.Variable{
+		probeOid:                  snmpgo.NewOctetString([]byte(sysObjectID)),
+		sysNameOid:                snmpgo.NewOctetString([]byte(sysName)),
+		sysDescrOid:               snmpgo.NewOctetString([]byte("simulator")),
+		".1.3.6.1.4.1.2021.4.5.0": snmpgo.NewInteger(1024),
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	Convey("Discovery of SNMP agents", t, func() {
+		snmpConnections = make(map[string]*connection)
+		discoverers = make(map[string]*discoverer)
+
+		mock := &agentsMock{handlers: make(map[*snmpgo.SNMP]string), agents: map[string]map[string]snmpgo.Variable{
+			"127.0.0.1:161": newAgent("router1", "1.3.6.1.4.1.9.1.516"),
+			"127.0.0.3:161": newAgent("printer1", "1.3.6.1.4.1.11.2.3.9.1"),
+		}}
+		snmp_ = mock
+
+		createMockFile(mockFileCont)
+		defer deleteMockFile()
+
+		config := plugin.NewConfig()
+		config["snmp_version"] = "v2c"
+		config["credential_sets"] = `[{"name": "old", "community": "public-old"}, {"name": "new", "community": "public-new"}]`
+		config["discovery_ranges"] = "127.0.0.0/29"
+		config["discovery_rate"] = int64(1000)
+		config["discovery_profiles"] = `{"1.3.6.1.4.1.9": "host"}`
+		config[setFileConfigVar] = mockFilePath
+
+		discovery, ok, err := configReader.GetDiscoveryConfig(config)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+
+		d := getDiscoverer(discovery)
+		d.sweep(discovery)
+
+		Convey("responsive SNMP agents are targets", func() {
+			targets := d.getTargets(discovery)
+			So(targets, ShouldHaveLength, 2)
+			So(targets[0].sysName, ShouldEqual, "router1")
+			So(targets[0].sysDescr, ShouldEqual, "simulator")
+			So(targets[0].agentConfig.Address, ShouldEqual, "127.0.0.1:161")
+			So(targets[0].agentConfig.CredentialSet, ShouldEqual, "old")
+			So(targets[0].profiles, ShouldResemble, []string{"host"})
+			So(targets[1].sysName, ShouldEqual, "printer1")
+			So(targets[1].profiles, ShouldBeEmpty)
+		})
+
+		Convey("metrics are collected from targets", func() {
+			mts := []plugin.Metric{
+				plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hostName"), Config: config},
+				plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "host", "memory", "total_bytes"), Config: config},
+			}
+
+			metrics, err := New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 3)
+
+			names := map[string]int{}
+			for _, m := range metrics {
+				names[m.Tags[tagSnmpAgentName]]++
+				So(m.Tags[tagSysObjectID], ShouldNotBeEmpty)
+			}
+			//metrics of profile selected by sysObjectID are collected only from router
+			So(names, ShouldResemble, map[string]int{"router1": 2, "printer1": 1})
+		})
+
+		Convey("SNMP agents appear and disappear between sweeps", func() {
+			mock.mtx.Lock()
+			delete(mock.agents, "127.0.0.3:161")
+			mock.agents["127.0.0.6:161"] = newAgent("", "1.3.6.1.4.1.8072.3.2.10")
+			mock.mtx.Unlock()
+
+			d.sweep(discovery)
+
+			targets := d.getTargets(discovery)
+			So(targets, ShouldHaveLength, 2)
+			So(targets[0].sysName, ShouldEqual, "router1")
+			So(targets[1].agentConfig.Name, ShouldEqual, "127.0.0.6:161")
+		})
+	})
+}
+
+func TestDiscoverCatalog(t *testing.T) {
+	Convey("Discovering catalog of metric types in SNMP agent", t, func() {
+		snmpConnections = make(map[string]*connection)
+
+		agent := newAgent("router1", "1.3.6.1.4.1.9.1.516")
+		agent[".1.3.6.1.2.1.2.2.1.2.1"] = snmpgo.NewOctetString([]byte("eth0"))
+		agent[".1.3.6.1.2.1.2.2.1.2.2"] = snmpgo.NewOctetString([]byte("eth1"))
+		agent[".1.3.6.1.2.1.2.2.1.10.1"] = snmpgo.NewCounter32(100)
+		agent[".1.3.6.1.2.1.2.2.1.10.2"] = snmpgo.NewCounter32(200)
+		agent[".1.3.6.1.2.1.4.20.1.2.10.0.0.1"] = snmpgo.NewInteger(1)
+		snmp_ = &agentsMock{handlers: make(map[*snmpgo.SNMP]string), agents: map[string]map[string]snmpgo.Variable{"127.0.0.1:161": agent}}
+
+		createMockFile(catalogFileCont)
+		defer deleteMockFile()
+
+		config := plugin.NewConfig()
+		config["snmp_agent_address"] = "127.0.0.1"
+		config["snmp_version"] = "v2c"
+		config["community"] = "public"
+		config[setFileConfigVar] = mockFilePath
+
+		namespaces := func(mts []plugin.Metric) []string {
+			names := []string{}
+			for _, mt := range mts {
+				names = append(names, mt.Namespace.String())
+			}
+			return names
+		}
+
+		Convey("all defined metric types are returned if catalog is not discovered", func() {
+			mts, err := New().GetMetricTypes(config)
+			So(err, ShouldBeNil)
+			So(mts, ShouldHaveLength, 4)
+		})
+
+		Convey("metric types supported by SNMP agent are returned", func() {
+			config[discoverCatalogConfigVar] = true
+			mts, err := New().GetMetricTypes(config)
+			So(err, ShouldBeNil)
+			So(namespaces(mts), ShouldResemble, []string{"/intel/snmp/sysName", "/intel/snmp/if/*/in",
+				"/intel/snmp/if/eth0/in", "/intel/snmp/if/eth1/in", "/intel/snmp/ip/*/index"})
+			So(mts[2].Description, ShouldEqual, "inbound octets")
+
+			Convey("metrics requested with concrete and dynamic elements are collected once", func() {
+				requested := []plugin.Metric{
+					plugin.Metric{Namespace: mts[1].Namespace, Config: config},
+					plugin.Metric{Namespace: mts[2].Namespace, Config: config},
+				}
+				metrics, err := New().CollectMetrics(requested)
+				So(err, ShouldBeNil)
+				So(namespaces(metrics), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("all defined metric types are returned if SNMP agent does not respond", func() {
+			config[discoverCatalogConfigVar] = true
+			config["snmp_agent_address"] = "127.0.0.2"
+			mts, err := New().GetMetricTypes(config)
+			So(err, ShouldBeNil)
+			So(mts, ShouldHaveLength, 4)
+		})
+	})
+}
+
+func TestCollectCompositeIndex(t *testing.T) {
+	Convey("Collecting metrics of table with composite index", t, func() {
+		agent := newAgent("router1", "1.3.6.1.4.1.9.1.516")
+		//ipNetToMediaPhysAddress indexed by ifIndex and IP address, followed by ipNetToMediaType
+		agent[".1.3.6.1.2.1.4.22.1.2.2.10.0.0.1"] = snmpgo.NewOctetString([]byte{0, 1, 2, 3, 4, 5})
+		agent[".1.3.6.1.2.1.4.22.1.2.3.192.168.0.10"] = snmpgo.NewOctetString([]byte{0, 1, 2, 3, 4, 6})
+		agent[".1.3.6.1.2.1.4.22.1.4.2.10.0.0.1"] = snmpgo.NewInteger(3)
+
+		createMockFile([]byte(`[
+		  {
+			"mode": "table",
+			"namespace": [
+			  {"source": "string", "string": "arp"},
+			  {"source": "index", "name": "ifIndex", "description": "interface index", "oid_part": 10},
+			  {"source": "index", "name": "address", "description": "IP address", "oid_part": 11, "encoding": "ipv4"},
+			  {"source": "string", "string": "phys_address"}
+			],
+			"OID": ".1.3.6.1.2.1.4.22.1.2",
+			"description": "physical address"
+		  }
+		]`))
+		defer deleteMockFile()
+
+		config := plugin.NewConfig()
+		config[setFileConfigVar] = mockFilePath
+
+		metrics, err := collectFromAgent(agent, config, "/intel/snmp/arp/*/*/phys_address")
+		So(err, ShouldBeNil)
+		So(metrics, ShouldHaveLength, 2)
+		So(metrics, ShouldContainKey, "/intel/snmp/arp/2/10.0.0.1/phys_address")
+		So(metrics, ShouldContainKey, "/intel/snmp/arp/3/192.168.0.10/phys_address")
+	})
+}
+
+func TestCollectNeighborProfiles(t *testing.T) {
+	Convey("Collecting metrics of neighbor profiles", t, func() {
+		config := plugin.NewConfig()
+		config[profileConfigVar] = "lldp, cdp"
+
+		Convey("LLDP neighbors indexed by lldpRemTimeMark, lldpLocalPortNum and lldpRemIndex", func() {
+			agent := newAgent("switch1", "1.3.6.1.4.1.9.1.516")
+			//lldpLocPortIdSubtype and lldpLocPortId
+			agent[".1.0.8802.1.1.2.1.3.7.1.2.1"] = snmpgo.NewInteger(5)
+			agent[".1.0.8802.1.1.2.1.3.7.1.3.1"] = snmpgo.NewOctetString([]byte("eth1"))
+			//lldpRemChassisIdSubtype and lldpRemChassisId of two neighbors on the same local port
+			agent[".1.0.8802.1.1.2.1.4.1.1.4.0.1.1"] = snmpgo.NewInteger(4)
+			agent[".1.0.8802.1.1.2.1.4.1.1.4.0.1.2"] = snmpgo.NewInteger(4)
+			agent[".1.0.8802.1.1.2.1.4.1.1.5.0.1.1"] = snmpgo.NewOctetString([]byte{0, 0x11, 0x22, 0x33, 0x44, 0x55})
+			agent[".1.0.8802.1.1.2.1.4.1.1.5.0.1.2"] = snmpgo.NewOctetString([]byte{0, 0x11, 0x22, 0x33, 0x44, 0x66})
+
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/lldp/neighbor/*/*/chassis_id")
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 2)
+			So(metrics, ShouldContainKey, "/intel/snmp/lldp/neighbor/eth1/1/chassis_id")
+			So(metrics, ShouldContainKey, "/intel/snmp/lldp/neighbor/eth1/2/chassis_id")
+			So(metrics["/intel/snmp/lldp/neighbor/eth1/2/chassis_id"].Data, ShouldEqual, "00:11:22:33:44:66")
+		})
+
+		Convey("CDP neighbors indexed by ifIndex and cdpCacheDeviceIndex", func() {
+			agent := newAgent("switch1", "1.3.6.1.4.1.9.1.516")
+			//ifNumber and ifName
+			agent[".1.3.6.1.2.1.2.1.0"] = snmpgo.NewInteger(3)
+			agent[".1.3.6.1.2.1.31.1.1.1.1.3"] = snmpgo.NewOctetString([]byte("eth3"))
+			//cdpCacheDeviceId of two neighbors on the same interface
+			agent[".1.3.6.1.4.1.9.9.23.1.2.1.1.6.3.1"] = snmpgo.NewOctetString([]byte("switch2"))
+			agent[".1.3.6.1.4.1.9.9.23.1.2.1.1.6.3.2"] = snmpgo.NewOctetString([]byte("switch3"))
+
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/cdp/neighbor/*/*/device_id")
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 2)
+			So(metrics, ShouldContainKey, "/intel/snmp/cdp/neighbor/eth3/1/device_id")
+			So(metrics["/intel/snmp/cdp/neighbor/eth3/2/device_id"].Data, ShouldEqual, "switch3")
+		})
+	})
+}
+
+func TestCollectRoutingProfile(t *testing.T) {
+	Convey("Collecting metrics of routing profile", t, func() {
+		config := plugin.NewConfig()
+		config[profileConfigVar] = "routing"
+
+		agent := newAgent("router1", "1.3.6.1.4.1.9.1.516")
+		//bgpPeerState and bgpPeerRemoteAs indexed by bgpPeerRemoteAddr
+		agent[".1.3.6.1.2.1.15.3.1.2.10.0.0.2"] = snmpgo.NewInteger(6)
+		agent[".1.3.6.1.2.1.15.3.1.2.10.0.0.3"] = snmpgo.NewInteger(3)
+		agent[".1.3.6.1.2.1.15.3.1.9.10.0.0.2"] = snmpgo.NewInteger(65001)
+		agent[".1.3.6.1.2.1.15.3.1.9.10.0.0.3"] = snmpgo.NewInteger(65002)
+		//ospfNbrRtrId and ospfNbrState indexed by ospfNbrIpAddr and ospfNbrAddressLessIndex, two unnumbered neighbors share 0.0.0.0
+		agent[".1.3.6.1.2.1.14.10.1.3.0.0.0.0.5"] = snmpgo.NewIpaddress(192, 168, 0, 5)
+		agent[".1.3.6.1.2.1.14.10.1.3.0.0.0.0.6"] = snmpgo.NewIpaddress(192, 168, 0, 6)
+		agent[".1.3.6.1.2.1.14.10.1.3.10.0.1.2.0"] = snmpgo.NewIpaddress(192, 168, 1, 2)
+		agent[".1.3.6.1.2.1.14.10.1.6.0.0.0.0.5"] = snmpgo.NewInteger(8)
+		agent[".1.3.6.1.2.1.14.10.1.6.0.0.0.0.6"] = snmpgo.NewInteger(1)
+		agent[".1.3.6.1.2.1.14.10.1.6.10.0.1.2.0"] = snmpgo.NewInteger(8)
+
+		Convey("BGP peers are named by remote IP address", func() {
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/bgp/peer/*/state")
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 2)
+			So(metrics["/intel/snmp/bgp/peer/10.0.0.2/state"].Data, ShouldEqual, "established")
+			So(metrics["/intel/snmp/bgp/peer/10.0.0.3/state"].Tags["bgpPeerRemoteAs"], ShouldEqual, "65002")
+		})
+
+		Convey("OSPF neighbors are named by IP address and address less index", func() {
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/ospf/neighbor/*/*/state")
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 3)
+			So(metrics["/intel/snmp/ospf/neighbor/0.0.0.0/5/state"].Data, ShouldEqual, "full")
+			So(metrics["/intel/snmp/ospf/neighbor/0.0.0.0/6/state"].Data, ShouldEqual, "down")
+			So(metrics["/intel/snmp/ospf/neighbor/0.0.0.0/6/state"].Tags["ospfNbrRtrId"], ShouldEqual, "192.168.0.6")
+			So(metrics["/intel/snmp/ospf/neighbor/10.0.1.2/0/state"].Data, ShouldEqual, "full")
+		})
+	})
+}
+
+func TestCollectPrinterProfile(t *testing.T) {
+	Convey("Collecting metrics of printer profile", t, func() {
+		config := plugin.NewConfig()
+		config[profileConfigVar] = "printer"
+
+		agent := newAgent("printer1", "1.3.6.1.4.1.11.2.3.9.1")
+		//prtMarkerSuppliesTable indexed by hrDeviceIndex and prtMarkerSuppliesIndex
+		agent[".1.3.6.1.2.1.43.11.1.1.5.1.1"] = snmpgo.NewInteger(3)
+		agent[".1.3.6.1.2.1.43.11.1.1.5.1.2"] = snmpgo.NewInteger(3)
+		agent[".1.3.6.1.2.1.43.11.1.1.6.1.1"] = snmpgo.NewOctetString([]byte("black"))
+		agent[".1.3.6.1.2.1.43.11.1.1.6.1.2"] = snmpgo.NewOctetString([]byte("cyan"))
+		agent[".1.3.6.1.2.1.43.11.1.1.7.1.1"] = snmpgo.NewInteger(19)
+		agent[".1.3.6.1.2.1.43.11.1.1.7.1.2"] = snmpgo.NewInteger(19)
+		agent[".1.3.6.1.2.1.43.11.1.1.8.1.1"] = snmpgo.NewInteger(200)
+		agent[".1.3.6.1.2.1.43.11.1.1.8.1.2"] = snmpgo.NewInteger(100)
+		agent[".1.3.6.1.2.1.43.11.1.1.9.1.1"] = snmpgo.NewInteger(50)
+		agent[".1.3.6.1.2.1.43.11.1.1.9.1.2"] = snmpgo.NewInteger(-3)
+		//prtMarkerLifeCount of markers with the same prtMarkerIndex on two printer devices
+		agent[".1.3.6.1.2.1.43.10.2.1.3.1.1"] = snmpgo.NewInteger(7)
+		agent[".1.3.6.1.2.1.43.10.2.1.3.2.1"] = snmpgo.NewInteger(7)
+		agent[".1.3.6.1.2.1.43.10.2.1.4.1.1"] = snmpgo.NewCounter32(12345)
+		agent[".1.3.6.1.2.1.43.10.2.1.4.2.1"] = snmpgo.NewCounter32(678)
+		//prtAlertSeverityLevel, prtAlertCode and prtAlertDescription
+		agent[".1.3.6.1.2.1.43.18.1.1.2.1.3"] = snmpgo.NewInteger(4)
+		agent[".1.3.6.1.2.1.43.18.1.1.7.1.3"] = snmpgo.NewInteger(808)
+		agent[".1.3.6.1.2.1.43.18.1.1.8.1.3"] = snmpgo.NewOctetString([]byte("Toner low"))
+
+		Convey("supplies indexed by hrDeviceIndex and prtMarkerSuppliesIndex", func() {
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/printer/supply/*/level_percent")
+			So(err, ShouldBeNil)
+
+			//level of cyan supply is not returned, at least one unit remains (-3)
+			So(metrics, ShouldHaveLength, 1)
+			So(metrics["/intel/snmp/printer/supply/black/level_percent"].Data, ShouldEqual, 25)
+			So(metrics["/intel/snmp/printer/supply/black/level_percent"].Tags["prtMarkerSuppliesType"], ShouldEqual, "toner")
+			So(metrics["/intel/snmp/printer/supply/black/level_percent"].Tags["prtMarkerSuppliesSupplyUnit"], ShouldEqual, "percent")
+		})
+
+		Convey("markers are named by hrDeviceIndex and prtMarkerIndex", func() {
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/printer/marker/*/*/life_count")
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 2)
+			So(metrics["/intel/snmp/printer/marker/1/1/life_count"].Tags["prtMarkerCounterUnit"], ShouldEqual, "impressions")
+			So(metrics, ShouldContainKey, "/intel/snmp/printer/marker/2/1/life_count")
+		})
+
+		Convey("alerts are named by hrDeviceIndex and prtAlertIndex", func() {
+			metrics, err := collectFromAgent(agent, config, "/intel/snmp/printer/alert/*/*/severity")
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 1)
+			So(metrics["/intel/snmp/printer/alert/1/3/severity"].Data, ShouldEqual, "warning")
+			So(metrics["/intel/snmp/printer/alert/1/3/severity"].Tags["prtAlertDescription"], ShouldEqual, "Toner low")
+		})
+	})
+}
+
+func TestTraceCollection(t *testing.T) {
+	Convey("Tracing collection of metrics", t, func() {
+		snmpConnections = make(map[string]*connection)
+
+		agent := newAgent("router1", "1.3.6.1.4.1.9.1.516")
+		agent[".1.3.6.1.2.1.2.2.1.2.1"] = snmpgo.NewOctetString([]byte("eth0"))
+		agent[".1.3.6.1.2.1.2.2.1.10.1"] = snmpgo.NewCounter32(100)
+		mock := &agentsMock{handlers: make(map[*snmpgo.SNMP]string), agents: map[string]map[string]snmpgo.Variable{"127.0.0.1:161": agent}}
+		snmp_ = mock
+
+		createMockFile(catalogFileCont)
+		defer deleteMockFile()
+
+		config := plugin.NewConfig()
+		config["snmp_agent_address"] = "127.0.0.1"
+		config["snmp_version"] = "v2c"
+		config["community"] = "public"
+		config[setFileConfigVar] = mockFilePath
+
+		trace, err := TraceCollection(config, "/intel/snmp/*")
+		So(err, ShouldBeNil)
+		So(trace.Metrics, ShouldHaveLength, 3)
+		So(trace.Duration, ShouldBeGreaterThan, 0)
+
+		oids := []string{}
+		requests := map[string]int{}
+		for _, e := range trace.Exchanges {
+			So(e.Agent, ShouldEqual, "127.0.0.1:161")
+			oids = append(oids, e.Oid)
+			requests[e.Oid] = e.Requests
+		}
+		So(oids, ShouldContain, ".1.3.6.1.2.1.1.5.0")
+		So(oids, ShouldContain, ".1.3.6.1.2.1.2.2.1.10")
+		So(oids, ShouldContain, ".1.3.6.1.2.1.2.2.1.2")
+
+		//GET request for scalar, GETNEXT requests for each result of walk and the last one which leaves subtree
+		So(requests[".1.3.6.1.2.1.1.5.0"], ShouldEqual, 1)
+		So(requests[".1.3.6.1.2.1.2.2.1.2"], ShouldEqual, 2)
+
+		//SNMP layer of plugin is restored
+		So(snmp_, ShouldEqual, mock)
+	})
+}
+
+func TestCaptureReplay(t *testing.T) {
+	Convey("Recording and replaying collections", t, func() {
+		snmpConnections = make(map[string]*connection)
+
+		agent := newAgent("router1", "1.3.6.1.4.1.9.1.516")
+		agent[".1.3.6.1.2.1.2.2.1.2.1"] = snmpgo.NewOctetString([]byte("eth0"))
+		agent[".1.3.6.1.2.1.2.2.1.10.1"] = snmpgo.NewCounter32(100)
+		agent[".1.3.6.1.2.1.4.20.1.2.10.0.0.1"] = snmpgo.NewInteger(1)
+		mock := &agentsMock{handlers: make(map[*snmpgo.SNMP]string), agents: map[string]map[string]snmpgo.Variable{"127.0.0.1:161": agent}}
+		snmp_ = newCapturingSnmp(mock)
+
+		createMockFile(catalogFileCont)
+		defer deleteMockFile()
+
+		captureFile, err := ioutil.TempFile("", "capture")
+		So(err, ShouldBeNil)
+		captureFile.Close()
+		defer os.Remove(captureFile.Name())
+
+		config := plugin.NewConfig()
+		config["snmp_agent_address"] = "127.0.0.1"
+		config["snmp_version"] = "v2c"
+		config["community"] = "public"
+		config["capture_mode"] = "record"
+		config["capture_file"] = captureFile.Name()
+		config[setFileConfigVar] = mockFilePath
+
+		mts := []plugin.Metric{plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "*"), Config: config}}
+		recorded, err := New().CollectMetrics(mts)
+		So(err, ShouldBeNil)
+		So(recorded, ShouldHaveLength, 4)
+
+		content, err := ioutil.ReadFile(captureFile.Name())
+		So(err, ShouldBeNil)
+		So(string(content), ShouldContainSubstring, `"request":"getnext","oid":".1.3.6.1.2.1.2.2.1.10"`)
+		So(string(content), ShouldContainSubstring, `"request":"get","oid":".1.3.6.1.2.1.1.5.0"`)
+		So(string(content), ShouldNotContainSubstring, "public")
+
+		Convey("collections are replayed without SNMP agent", func() {
+			mock.mtx.Lock()
+			delete(mock.agents, "127.0.0.1:161")
+			mock.mtx.Unlock()
+
+			config["capture_mode"] = "replay"
+			replayed, err := New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(replayed, ShouldHaveLength, len(recorded))
+
+			values := map[string]interface{}{}
+			for _, m := range recorded {
+				values[m.Namespace.String()] = m.Data
+			}
+			for _, m := range replayed {
+				So(values, ShouldContainKey, m.Namespace.String())
+				So(m.Data, ShouldResemble, values[m.Namespace.String()])
+			}
+		})
+
+		Convey("handlers are removed when connections are closed", func() {
+			capture := snmp_.(*capturingSnmp)
+			capture.mtx.Lock()
+			So(capture.handlers, ShouldNotBeEmpty)
+			capture.mtx.Unlock()
+
+			mtxSnmpConnections.Lock()
+			for key, conn := range snmpConnections {
+				closeConnection(key, conn)
+			}
+			mtxSnmpConnections.Unlock()
+
+			capture.mtx.Lock()
+			So(capture.handlers, ShouldBeEmpty)
+			capture.mtx.Unlock()
+		})
+
+		Convey("request which is not recorded fails in replay", func() {
+			capture := snmp_.(*capturingSnmp)
+			handler, err := capture.newHandler(configReader.SnmpAgent{Address: "127.0.0.1:161", CaptureMode: "replay", CaptureFile: captureFile.Name()})
+			So(err, ShouldBeNil)
+
+			_, err = capture.readElements(handler, ".1.3.6.1.2.1.1.3.0", configReader.ModeSingle)
+			So(err, ShouldNotBeNil)
+
+			results, err := capture.readElements(handler, ".1.3.6.1.2.1.1.5.0", configReader.ModeSingle)
+			So(err, ShouldBeNil)
+			So(results[0].Variable.String(), ShouldEqual, "router1")
+		})
+	})
+}
+
+type errorsMock struct {
+	mtx   sync.Mutex
+	errs  []error
+	reads int
+}
+
+func (m *errorsMock) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
+	return &snmpgo.SNMP{}, nil
+}
+
+func (m *errorsMock) readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	return snmp.Walk(func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+		return m.sendRequest(handler, pduType, oid)
+	}, oid, mode)
+}
+
+//sendRequest returns errors in order, errors of SNMP agent are returned as error status of response
+func (m *errorsMock) sendRequest(handler *snmpgo.SNMP, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.reads++
+
+	pdu := snmpgo.NewPdu(snmpgo.V2c, snmpgo.GetResponse)
+	newOid, _ := snmpgo.NewOid(oid)
+	if m.reads <= len(m.errs) {
+		switch e := m.errs[m.reads-1].(type) {
+		case *snmp.TooBigError:
+			pdu.SetErrorStatus(snmpgo.TooBig)
+			pdu.SetErrorIndex(e.Index)
+		case *snmp.AgentError:
+			pdu.SetErrorStatus(e.Status)
+			pdu.SetErrorIndex(e.Index)
+		default:
+			return nil, e
+		}
+		pdu.AppendVarBind(newOid, snmpgo.NewNull())
+		return pdu, nil
+	}
+	pdu.AppendVarBind(newOid, snmpgo.NewOctetString([]byte("value")))
+	return pdu, nil
+}
+
+func (m *errorsMock) closeHandler(handler *snmpgo.SNMP) {}
+
+func TestCollectionErrors(t *testing.T) {
+	Convey("Reacting to errors of SNMP agent in collection", t, func() {
+		snmpConnections = make(map[string]*connection)
+		errorCounts = make(map[string]map[string]uint64)
+
+		createMockFile(mockFileCont)
+		defer deleteMockFile()
+
+		config := plugin.NewConfig()
+		config["snmp_version"] = "v3"
+		config["snmp_agent_address"] = "127.0.0.3"
+		config["user_name"] = "user"
+		config["security_level"] = "AuthNoPriv"
+		config["auth_protocol"] = "MD5"
+		config["auth_password"] = "password"
+		config[setFileConfigVar] = mockFilePath
+
+		mts := []plugin.Metric{
+			plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hostName"), Config: config},
+			plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hrSystemNumUsers"), Config: config},
+			plugin.Metric{Namespace: plugin.NewNamespace(Vendor, PluginName, "hrSystemProcesses"), Config: config},
+		}
+
+		Convey("the remaining metrics are not read after timeout", func() {
+			mock := &errorsMock{errs: []error{&snmp.TimeoutError{Cause: fmt.Errorf("i/o timeout")}}}
+			snmp_ = mock
+
+			metrics, err := New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(mock.reads, ShouldEqual, 1)
+			So(ErrorCounts()["127.0.0.3:161"], ShouldResemble, map[string]uint64{snmp.CategoryTimeout: 1})
+			So(connectionAddresses(), ShouldContain, "127.0.0.3:161")
+		})
+
+		Convey("read is retried once after malformed response", func() {
+			mock := &errorsMock{errs: []error{&snmp.MalformedResponseError{Message: "Unaccepted number of results, received 2 results"}}}
+			snmp_ = mock
+
+			metrics, err := New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 3)
+			So(mock.reads, ShouldEqual, 4)
+			So(ErrorCounts()["127.0.0.3:161"], ShouldResemble, map[string]uint64{snmp.CategoryMalformed: 1})
+		})
+
+		Convey("connection is opened again after authentication failure", func() {
+			authErr := &snmp.AuthError{Report: "usmStatsWrongDigests", Cause: fmt.Errorf("Received a report from the agent")}
+			mock := &errorsMock{errs: []error{authErr}}
+			snmp_ = mock
+
+			metrics, err := New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(metrics, ShouldBeEmpty)
+			So(mock.reads, ShouldEqual, 1)
+			So(ErrorCounts()["127.0.0.3:161"], ShouldResemble, map[string]uint64{snmp.CategoryAuth: 1})
+			So(connectionAddresses(), ShouldNotContain, "127.0.0.3:161")
+		})
+
+		Convey("errors are counted by category", func() {
+			snmp_ = &errorsMock{errs: []error{&snmp.TooBigError{Index: 1}, &snmp.AgentError{Status: snmpgo.GenError, Index: 1}}}
+
+			metrics, err := New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(metrics, ShouldHaveLength, 1)
+			So(ErrorCounts()["127.0.0.3:161"], ShouldResemble, map[string]uint64{snmp.CategoryTooBig: 1, snmp.CategoryAgent: 1})
+			So(connectionAddresses(), ShouldContain, "127.0.0.3:161")
+
+			_, err = New().CollectMetrics(mts)
+			So(err, ShouldBeNil)
+			So(ErrorCounts()["127.0.0.3:161"], ShouldResemble, map[string]uint64{snmp.CategoryTooBig: 1, snmp.CategoryAgent: 1})
+		})
+
+		Convey("type of error is kept in capture file", func() {
+			captureFile, err := ioutil.TempFile("", "capture")
+			So(err, ShouldBeNil)
+			captureFile.Close()
+			defer os.Remove(captureFile.Name())
+
+			errs := []error{
+				&snmp.TimeoutError{Cause: fmt.Errorf("i/o timeout")},
+				&snmp.AuthError{Report: "usmStatsUnknownUserNames", Cause: fmt.Errorf("Received a report from the agent")},
+				&snmp.TooBigError{Index: 1},
+				&snmp.AgentError{Status: snmpgo.GenError, Index: 1},
+				&snmp.MalformedResponseError{Message: "Unaccepted number of results, received 0 results"},
+			}
+			capture := newCapturingSnmp(&errorsMock{errs: errs})
+			agentConfig := configReader.SnmpAgent{Address: "127.0.0.3:161", CaptureMode: "record", CaptureFile: captureFile.Name()}
+			handler, err := capture.newHandler(agentConfig)
+			So(err, ShouldBeNil)
+			for i, e := range errs {
+				_, err := capture.readElements(handler, fmt.Sprintf(".1.3.6.1.2.1.1.%d.0", i+1), configReader.ModeSingle)
+				So(err, ShouldResemble, e)
+			}
+			capture.closeHandler(handler)
+
+			agentConfig.CaptureMode = "replay"
+			handler, err = capture.newHandler(agentConfig)
+			So(err, ShouldBeNil)
+			for i, e := range errs {
+				_, err := capture.readElements(handler, fmt.Sprintf(".1.3.6.1.2.1.1.%d.0", i+1), configReader.ModeSingle)
+				So(err, ShouldResemble, e)
+			}
+		})
+	})
+}
+
+func TestConcurrentCollections(t *testing.T) {
+	Convey("Collecting metrics concurrently from many SNMP agents", t, func() {
+		snmpConnections = make(map[string]*connection)
+
+		addresses := map[string]string{"agent1": "127.0.0.1:1161", "agent2": "127.0.0.1:1162"}
+		agents := map[string]map[string]snmpgo.Variable{}
+		for name, address := range addresses {
+			agents[address] = map[string]snmpgo.Variable{
+				".1.3.6.1.2.1.1.9.1.3.1": snmpgo.NewOctetString([]byte(name + "-descr1")),
+				".1.3.6.1.2.1.1.9.1.3.2": snmpgo.NewOctetString([]byte(name + "-descr2")),
+				".1.3.6.1.2.1.1.9.1.4.1": snmpgo.NewOctetString([]byte(name + "-uptime1")),
+				".1.3.6.1.2.1.1.9.1.4.2": snmpgo.NewOctetString([]byte(name + "-uptime2")),
+			}
+		}
+		snmp_ = &agentsMock{handlers: make(map[*snmpgo.SNMP]string), agents: agents}
+
+		createMockFile(mockFileCont)
+		defer deleteMockFile()
+
+		plg := New()
+		namespace := plugin.NewNamespace(Vendor, PluginName, "system", "sysORTable", "sysOREntry", "sysORDescr", "*", "value")
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 100)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				name := []string{"agent1", "agent2"}[i%2]
+				config := plugin.NewConfig()
+				config["snmp_agent_name"] = name
+				config["snmp_agent_address"] = addresses[name]
+				config["snmp_version"] = "v2c"
+				config["community"] = "public"
+				config[setFileConfigVar] = mockFilePath
+
+				for j := 0; j < 20; j++ {
+					metrics, err := plg.CollectMetrics([]plugin.Metric{plugin.Metric{Namespace: namespace, Config: config}})
+					if err != nil {
+						errs <- err
+						return
+					}
+					if len(metrics) != 2 {
+						errs <- fmt.Errorf("%s: expected 2 metrics, got %d", name, len(metrics))
+						return
+					}
+					for _, m := range metrics {
+						descr := m.Namespace[6].Value
+						index := strings.TrimPrefix(descr, name+"-descr")
+						if m.Tags[tagSnmpAgentName] != name || index == descr || m.Data != name+"-uptime"+index {
+							errs <- fmt.Errorf("%s: incorrect metric %s with value %v", name, m.Namespace.String(), m.Data)
+							return
+						}
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			So(err, ShouldBeNil)
+		}
+		So(snmpConnections, ShouldHaveLength, 2)
+		for _, conn := range snmpConnections {
+			So(conn.users, ShouldEqual, 0)
+		}
+	})
+}
+
+func TestConnectionUsers(t *testing.T) {
+	Convey("Closing connections which are in use", t, func() {
+		snmpConnections = make(map[string]*connection)
+		snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+			elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+
+		agentConfig := configReader.SnmpAgent{Network: "udp", Address: "127.0.0.1:161"}
+		conn, err := acquireConnection(agentConfig)
+		So(err, ShouldBeNil)
+		So(conn.users, ShouldEqual, 1)
+
+		mtxSnmpConnections.Lock()
+		closeConnection(connectionKey(agentConfig), conn)
+		mtxSnmpConnections.Unlock()
+
+		//removed connection is not reused, it is closed when collection ends
+		So(snmpConnections, ShouldBeEmpty)
+		So(conn.closed, ShouldBeTrue)
+
+		other, err := acquireConnection(agentConfig)
+		So(err, ShouldBeNil)
+		So(other, ShouldNotPointTo, conn)
+
+		releaseConnection(conn)
+		releaseConnection(other)
+		So(conn.users, ShouldEqual, 0)
+		So(snmpConnections, ShouldContainKey, connectionKey(agentConfig))
+	})
+}
+
+func TestLabelCache(t *testing.T) {
+	Convey("Caching namespace elements and tags between collections", t, func() {
+		ifDescr := ".1.3.6.1.2.1.2.2.1.2"
+		ifTableLastChange := ".1.3.6.1.2.1.31.1.5.0"
+		mock := &tableMock{tables: map[string][]*snmpgo.VarBind{
+			ifDescr: newVarBinds(ifDescr, map[string]snmpgo.Variable{
+				".1": snmpgo.NewOctetString([]byte("lo")), ".2": snmpgo.NewOctetString([]byte("eth0"))}),
+			ifTableLastChange: newVarBinds(ifTableLastChange, map[string]snmpgo.Variable{"": snmpgo.NewTimeTicks(100)}),
+			sysUpTimeOid:      newVarBinds(sysUpTimeOid, map[string]snmpgo.Variable{"": snmpgo.NewTimeTicks(1000)}),
+		}, reads: map[string]int{}}
+		snmp_ = mock
+		labels := newLabelCache()
+
+		metric := &configReader.Metric{Oid: ".1.3.6.1.2.1.31.1.1.1.6", Mode: "table"}
+		results := newVarBinds(metric.Oid, map[string]snmpgo.Variable{".2": snmpgo.NewCounter64(10)})
+		j := join{oid: ifDescr, join: "index", cacheTTL: 60, cacheInvalidateOid: ifTableLastChange}
+
+		collect := func() []string {
+			values, err := resolveJoin(nil, newTableCache(labels), results, metric, j)
+			So(err, ShouldBeNil)
+			return values
+		}
+
+		Convey("when cached labels are valid", func() {
+			So(collect(), ShouldResemble, []string{"eth0"})
+			So(collect(), ShouldResemble, []string{"eth0"})
+			So(mock.reads[ifDescr], ShouldEqual, 1)
+			So(mock.reads[ifTableLastChange], ShouldEqual, 2)
+			So(mock.reads[sysUpTimeOid], ShouldEqual, 2)
+		})
+
+		Convey("when caching is not configured", func() {
+			j.cacheTTL = 0
+			j.cacheInvalidateOid = ""
+			collect()
+			collect()
+			So(mock.reads[ifDescr], ShouldEqual, 2)
+			So(mock.reads[sysUpTimeOid], ShouldEqual, 0)
+		})
+
+		Convey("when cached labels expire", func() {
+			collect()
+			labels.entries[labelCacheKey(ifDescr, "walk")] = labelCacheEntry{expires: time.Now(), triggerValue: "100"}
+			So(collect(), ShouldResemble, []string{"eth0"})
+			So(mock.reads[ifDescr], ShouldEqual, 2)
+		})
+
+		Convey("when value of invalidation OID changes", func() {
+			collect()
+			mock.tables[ifTableLastChange] = newVarBinds(ifTableLastChange, map[string]snmpgo.Variable{"": snmpgo.NewTimeTicks(200)})
+			collect()
+			So(mock.reads[ifDescr], ShouldEqual, 2)
+		})
+
+		Convey("when SNMP agent is restarted", func() {
+			collect()
+			mock.tables[sysUpTimeOid] = newVarBinds(sysUpTimeOid, map[string]snmpgo.Variable{"": snmpgo.NewTimeTicks(10)})
+			collect()
+			So(mock.reads[ifDescr], ShouldEqual, 2)
+		})
+
+		Convey("when index is missing in cached labels", func() {
+			collect()
+			mock.tables[ifDescr] = append(mock.tables[ifDescr], newVarBinds(ifDescr, map[string]snmpgo.Variable{
+				".3": snmpgo.NewOctetString([]byte("eth1"))})...)
+			results = newVarBinds(metric.Oid, map[string]snmpgo.Variable{".3": snmpgo.NewCounter64(10)})
+			So(collect(), ShouldResemble, []string{"eth1"})
+			So(mock.reads[ifDescr], ShouldEqual, 2)
+		})
+	})
+}
+
+func TestGetDynamicNamespaceElements(t *testing.T) {
+	Convey("Calling getDynamicNamespaceElements ", t, func() {
+
+		//create SNMP agent config
+		agentConfig := make(map[string]interface{})
+		agentConfig["snmp_agent_name"] = "agent1"
+		agentConfig["snmp_agent_address"] = "127.0.0.1"
+		agentConfig["snmp_version"] = "v2c"
+		agentConfig["community"] = "public"
+		snmpAgentConfig, _ := configReader.GetSnmpAgentConfig(agentConfig)
+
+		Convey("with incorrect value of `oid_part` parameter", func() {
+
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+
+			//metric configuration
+			metricConfig := []configReader.Metric{configReader.Metric{
+				Oid:  ".1.3.6.1.2.1.1.2.0",
+				Mode: "table",
+				Namespace: []configReader.Namespace{
+					configReader.Namespace{Source: "string", String: "test1"},
+					configReader.Namespace{Source: "string", String: "test2"},
+					configReader.Namespace{Source: "index", OidPart: 102, Name: "name"},
+					configReader.Namespace{Source: "string", String: "value"},
+				},
+				Unit:        "unit",
+				Description: "description",
+			}}
+
+			//create results
+			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+			So(err, ShouldBeNil)
+
+			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
+			varBinds := []*snmpgo.VarBind{varBind}
+
+			handler, err := snmp_.newHandler(snmpAgentConfig)
+			So(err, ShouldBeNil)
+
+			_, serr := getDynamicNamespaceElements(handler, newTableCache(nil), varBinds, &metricConfig[0])
+			So(serr, ShouldNotBeNil)
+
+		})
+
+		Convey("with incorrect configuration of dynamic elements of namespace, the number of results is not equal the number of dynamic elements of namespace ", func() {
+
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+
+			//metric configuration
+			metricConfig := []configReader.Metric{configReader.Metric{
+				Oid:  ".1.3.6.1.2.1.1.2.0",
+				Mode: "table",
+				Namespace: []configReader.Namespace{
+					configReader.Namespace{Source: "string", String: "test1"},
+					configReader.Namespace{Source: "string", String: "test2"},
+					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name"},
+					configReader.Namespace{Source: "string", String: "value"},
+				},
+				Unit:        "unit",
+				Description: "description",
+			}}
+
+			//create results
+			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+			So(err, ShouldBeNil)
+
+			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
+			varBinds := []*snmpgo.VarBind{varBind, varBind}
+
+			handler, err := snmp_.newHandler(snmpAgentConfig)
+			So(err, ShouldBeNil)
+
+			_, serr := getDynamicNamespaceElements(handler, newTableCache(nil), varBinds, &metricConfig[0])
+			So(serr, ShouldNotBeNil)
+
+		})
+
+		Convey("when SNMP request fails", func() {
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_INCORRECT]}
+
+			//metric configuration
+			metricConfig := []configReader.Metric{configReader.Metric{
+				Oid:  ".1.3.6.1.2.1.1.2.0",
+				Mode: "table",
+				Namespace: []configReader.Namespace{
+					configReader.Namespace{Source: "string", String: "test1"},
+					configReader.Namespace{Source: "string", String: "test2"},
+					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name1"},
+					configReader.Namespace{Source: "string", String: "value"},
+				},
+				Unit:        "unit",
+				Description: "description",
+			}}
+
+			//create results
+			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+			So(err, ShouldBeNil)
+
+			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
+			varBinds := []*snmpgo.VarBind{varBind}
+
+			handler, err := snmp_.newHandler(snmpAgentConfig)
+			So(err, ShouldBeNil)
+
+			_, serr := getDynamicNamespaceElements(handler, newTableCache(nil), varBinds, &metricConfig[0])
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("when dynamic elements of namespace are successfully received", func() {
+			snmp_ = &snmpMock{handlerEntry: snmpHandlerTestTable[SUCCESSFULLY_CREATED_HANDLER],
+				elementEntry: snmpElementTestTable[SNMP_ELEMENT_CORRECT_OCTET_STRING]}
+
+			//metric configuration
+			metricConfig := []configReader.Metric{configReader.Metric{
+				Oid:  ".1.3.6.1.2.1.1.2.0",
+				Mode: "table",
+				Namespace: []configReader.Namespace{
+					configReader.Namespace{Source: "string", String: "test1"},
+					configReader.Namespace{Source: "string", String: "test2"},
+					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name1"},
+					configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name2"},
+					configReader.Namespace{Source: "index", OidPart: 3, Name: "name3"},
+					configReader.Namespace{Source: "string", String: "value"},
+				},
+				Unit:        "unit",
+				Description: "description",
+			}}
+
+			//create results
+			newOid, err := snmpgo.NewOid(metricConfig[0].Oid)
+			So(err, ShouldBeNil)
+
+			varBind := snmpgo.NewVarBind(newOid, snmpgo.NewCounter32(123))
+			varBinds := []*snmpgo.VarBind{varBind}
+
+			handler, err := snmp_.newHandler(snmpAgentConfig)
+			So(err, ShouldBeNil)
+
+			_, serr := getDynamicNamespaceElements(handler, newTableCache(nil), varBinds, &metricConfig[0])
+			So(serr, ShouldBeNil)
+		})
+	})
+}
+
+func TestNamespaceMatcher(t *testing.T) {
+	Convey("Matching of namespaces", t, func() {
+		matcher := newNamespaceMatcher([]string{"intel", "snmp", "if", "*", "(in_octets;out_octets)"})
+
+		So(matcher.match([]string{"intel", "snmp", "if", "eth0", "in_octets"}), ShouldBeTrue)
+		So(matcher.match([]string{"intel", "snmp", "if", "eth0", "out_octets"}), ShouldBeTrue)
+		So(matcher.match([]string{"intel", "snmp", "if", "eth0", "in_errors"}), ShouldBeFalse)
+		So(matcher.match([]string{"intel", "snmp", "if", "eth0", "in_octets", "rate"}), ShouldBeFalse)
+		So(matcher.match([]string{"intel", "snmp", "ifx", "eth0", "in_octets"}), ShouldBeFalse)
+
+		//the last `*` matches all namespaces with prefix
+		matcher = newNamespaceMatcher([]string{"intel", "snmp", "*"})
+		So(matcher.match([]string{"intel", "snmp", "if", "eth0", "in_octets"}), ShouldBeTrue)
+		So(matcher.match([]string{"intel", "snmp", "hostName"}), ShouldBeTrue)
+		So(matcher.match([]string{"intel", "snmp"}), ShouldBeFalse)
+		So(matcher.match([]string{"intel", "snmpx", "hostName"}), ShouldBeFalse)
+
+		//dots are not special characters
+		matcher = newNamespaceMatcher([]string{"intel", "snmp", "a.b"})
+		So(matcher.match([]string{"intel", "snmp", "axb"}), ShouldBeFalse)
+		So(matcher.match([]string{"intel", "snmp", "a.b"}), ShouldBeTrue)
+
+		So(splitNamespace("/intel/snmp/if/*/in_octets"), ShouldResemble, []string{"intel", "snmp", "if", "*", "in_octets"})
+		So(splitNamespace("|intel|snmp|ip/mask"), ShouldResemble, []string{"intel", "snmp", "ip/mask"})
+	})
+}
+
+func TestGetMetricsToCollect(t *testing.T) {
+	Convey("Calling getMetricsToCollect ", t, func() {
+		metricConfig := configReader.Metric{
+			Oid:  ".1.3.6.1.2.1.1.2.0",
+			Mode: "table",
+			Namespace: []configReader.Namespace{
+				configReader.Namespace{Source: "string", String: "test1"},
+				configReader.Namespace{Source: "string", String: "test2"},
+				configReader.Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.1.9.1.3", Name: "name1"},
+				configReader.Namespace{Source: "string", String: "value"},
+			},
+			Unit:        "unit",
+			Description: "description",
+		}
+
+		metricsConfigs := map[string]configReader.Metric{
+			"/intel/snmp/test1/test2/*/value": metricConfig,
+			"/intel/snmp/test1/test3/*/value": metricConfig,
+		}
+
+		Convey("with correct arguments", func() {
+
+			collectedMetrics, serr := getMetricsToCollect(newNamespaceMatcher([]string{"intel", "snmp", "test1", "test2", "*", "value"}), metricsConfigs)
+
+			So(serr, ShouldBeNil)
+			So(len(collectedMetrics), ShouldEqual, 1)
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Oid, ShouldEqual, ".1.3.6.1.2.1.1.2.0")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Mode, ShouldEqual, "table")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Unit, ShouldEqual, "unit")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Description, ShouldEqual, "description")
+			So(len(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace), ShouldEqual, 4)
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[0].String, ShouldEqual, "test1")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[0].Source, ShouldEqual, "string")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[1].String, ShouldEqual, "test2")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[1].Source, ShouldEqual, "string")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[2].Source, ShouldEqual, "snmp")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[2].Oid, ShouldEqual, ".1.3.6.1.2.1.1.9.1.3")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[3].String, ShouldEqual, "value")
+			So(collectedMetrics["/intel/snmp/test1/test2/*/value"].Namespace[3].Source, ShouldEqual, "string")
+		})
+
+		Convey("with tuple and with value of dynamic element", func() {
+
+			collectedMetrics, serr := getMetricsToCollect(newNamespaceMatcher([]string{"intel", "snmp", "test1", "(test2|test3)", "eth0", "value"}), metricsConfigs)
+
+			So(serr, ShouldBeNil)
+			So(collectedMetrics, ShouldContainKey, "/intel/snmp/test1/test2/*/value")
+			So(collectedMetrics, ShouldContainKey, "/intel/snmp/test1/test3/*/value")
+		})
+
+		Convey("with namespaces which only contain namespace of metric", func() {
+			namespaces := [][]string{
+				{"intel", "snmp", "test1", "test2"},
+				{"intel", "snmp", "test1", "test2", "*", "value", "extra"},
+				{"intel", "snmp", "extra", "intel", "snmp", "test1", "test2", "*", "value"},
+				{"intel", "snmp", "test1", "(test4|test5)", "*", "value"},
+				{"intel", "snmp", "test1", "test2x", "*", "value"},
+			}
+			for _, namespace := range namespaces {
+				collectedMetrics, serr := getMetricsToCollect(newNamespaceMatcher(namespace), metricsConfigs)
+
+				So(serr, ShouldNotBeNil)
+				So(len(collectedMetrics), ShouldEqual, 0)
+			}
+		})
+	})
+}
+
+func connectionAddresses() []string {
+	addresses := []string{}
+	for _, conn := range snmpConnections {
+		addresses = append(addresses, conn.address)
+	}
+	return addresses
+}
+
+func createMockFile(fileCont []byte) {
+	deleteMockFile()
+
+	f, _ := os.Create(mockFilePath)
+	f.Write(fileCont)
 }
 
 func deleteMockFile() {
@@ -747,6 +2522,32 @@ var (
 
 	mockFilePath = "./temp_setfile.json"
 
+	catalogFileCont = []byte(`
+		[
+		  {"mode": "single", "namespace": [{"source": "string", "string": "sysName"}], "OID": ".1.3.6.1.2.1.1.5.0"},
+		  {"mode": "single", "namespace": [{"source": "string", "string": "sysContact"}], "OID": ".1.3.6.1.2.1.1.4.0"},
+		  {
+			"mode": "walk",
+			"namespace": [
+			  {"source": "string", "string": "if"},
+			  {"source": "snmp", "name": "interface", "description": "interface name", "OID": ".1.3.6.1.2.1.2.2.1.2"},
+			  {"source": "string", "string": "in"}
+			],
+			"OID": ".1.3.6.1.2.1.2.2.1.10",
+			"description": "inbound octets"
+		  },
+		  {
+			"mode": "walk",
+			"namespace": [
+			  {"source": "string", "string": "ip"},
+			  {"source": "index", "name": "address", "description": "IP address", "oid_part": 10, "encoding": "ipv4"},
+			  {"source": "string", "string": "index"}
+			],
+			"OID": ".1.3.6.1.2.1.4.20.1.2"
+		  }
+		]
+	`)
+
 	mockFileCont = []byte(`
 		[
 		 {
@@ -0,0 +1,103 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configReader
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	//defaultNetwork network which is used if network is not set in SNMP agent configuration
+	defaultNetwork = "udp"
+
+	//defaultPort SNMP agent port which is used if port is not set in SNMP agent address
+	defaultPort = "161"
+)
+
+var (
+	//networkOptions slice of options for network
+	networkOptions = []interface{}{"udp", "udp4", "udp6", "tcp", "tcp4", "tcp6"}
+)
+
+//normalizeAddress validates SNMP agent address and returns it in the form of `host:port` (`[host]:port` for IPv6),
+//host names are lower-cased, IP addresses are in canonical form and default port is added if port is not set
+func normalizeAddress(address string, network string) (string, error) {
+	host, port, err := splitHostPort(strings.TrimSpace(address))
+	if err != nil {
+		return "", err
+	}
+
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || portNumber == 0 {
+		return "", fmt.Errorf("Incorrect port (%s) in SNMP agent address (%s)", port, address)
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("Missing host in SNMP agent address (%s)", address)
+	}
+
+	//IPv6 address may contain zone (e.g. fe80::1%eth0)
+	ipString, zone := host, ""
+	if i := strings.LastIndex(host, "%"); i >= 0 {
+		ipString, zone = host[:i], host[i:]
+	}
+
+	if ip := net.ParseIP(ipString); ip != nil {
+		if ip.To4() != nil && strings.HasSuffix(network, "6") {
+			return "", fmt.Errorf("IPv4 address (%s) cannot be used with network (%s)", host, network)
+		}
+		if ip.To4() == nil && strings.HasSuffix(network, "4") {
+			return "", fmt.Errorf("IPv6 address (%s) cannot be used with network (%s)", host, network)
+		}
+		host = ip.String() + zone
+	} else if zone != "" {
+		return "", fmt.Errorf("Incorrect IP address (%s) in SNMP agent address (%s)", host, address)
+	} else {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+	}
+
+	return net.JoinHostPort(host, strconv.FormatUint(portNumber, 10)), nil
+}
+
+//splitHostPort splits address into host and port, address can be given without port and IPv6 address can be given without brackets
+func splitHostPort(address string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]"):
+		//bracketed IPv6 address without port
+		return address[1 : len(address)-1], defaultPort, nil
+	case strings.Count(address, ":") > 1 && !strings.HasPrefix(address, "["):
+		//IPv6 address without brackets and without port
+		return address, defaultPort, nil
+	case !strings.Contains(address, ":"):
+		//host name or IPv4 address without port
+		return address, defaultPort, nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", "", fmt.Errorf("Incorrect SNMP agent address (%s): %v", address, err)
+	}
+	return host, port, nil
+}
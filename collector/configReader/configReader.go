@@ -50,6 +50,54 @@ const (
 	//nsSourceIndex option in source of namespace element configuration
 	NsSourceIndex = "index"
 
+	//IndexEncodingInteger option in encoding of index namespace element, single OID element
+	IndexEncodingInteger = "integer"
+
+	//IndexEncodingIPv4 option in encoding of index namespace element, IPv4 address (4 OID elements)
+	IndexEncodingIPv4 = "ipv4"
+
+	//IndexEncodingIPv6 option in encoding of index namespace element, IPv6 address (16 OID elements)
+	IndexEncodingIPv6 = "ipv6"
+
+	//IndexEncodingMAC option in encoding of index namespace element, MAC address (6 OID elements)
+	IndexEncodingMAC = "mac"
+
+	//IndexEncodingInetAddress option in encoding of index namespace element, InetAddressType followed by length-prefixed InetAddress
+	IndexEncodingInetAddress = "inet_address"
+
+	//IndexEncodingString option in encoding of index namespace element, length-prefixed string
+	IndexEncodingString = "string"
+
+	//IndexEncodingImpliedString option in encoding of index namespace element, string without length (IMPLIED)
+	IndexEncodingImpliedString = "implied_string"
+
+	//IndexEncodingOid option in encoding of index namespace element, length-prefixed OID
+	IndexEncodingOid = "oid"
+
+	//IndexEncodingImpliedOid option in encoding of index namespace element, OID without length (IMPLIED)
+	IndexEncodingImpliedOid = "implied_oid"
+
+	//JoinIndex option in join of namespace element or tag, column of other table is read using index shared with metric table
+	JoinIndex = "index"
+
+	//JoinPointer option in join of namespace element or tag, column of other table is read using value of pointer column (`index_OID`)
+	JoinPointer = "pointer"
+
+	//ValueEncodingMAC option in encoding of value which depends on subtype, MAC address (6 octets)
+	ValueEncodingMAC = "mac"
+
+	//ValueEncodingIP option in encoding of value which depends on subtype, IPv4 or IPv6 address (4 or 16 octets)
+	ValueEncodingIP = "ip"
+
+	//ValueEncodingNetworkAddress option in encoding of value which depends on subtype, IANA address family followed by address (e.g. LLDP-MIB)
+	ValueEncodingNetworkAddress = "network_address"
+
+	//ValueEncodingString option in encoding of value which depends on subtype, printable string
+	ValueEncodingString = "string"
+
+	//ValueEncodingHex option in encoding of value which depends on subtype, octets in hexadecimal form separated by colons
+	ValueEncodingHex = "hex"
+
 	//agentName indicates SNMP agent name
 	agentName = "snmp_agent_name"
 
@@ -98,6 +146,33 @@ const (
 	//agentTimeout indicates timeout for network connection in SNMP agent configuration
 	agentTimeout = "timeout"
 
+	//agentCredentialsFile indicates path to encrypted credentials file in SNMP agent configuration
+	agentCredentialsFile = "credentials_file"
+
+	//agentCredentialsPassphrase indicates pass phrase of encrypted credentials file in SNMP agent configuration
+	agentCredentialsPassphrase = "credentials_passphrase"
+
+	//agentCredentialSets indicates ordered list of credential sets (JSON array) in SNMP agent configuration
+	agentCredentialSets = "credential_sets"
+
+	//agentCredentialSet indicates name of credential set in SNMP agent configuration
+	agentCredentialSet = "credential_set"
+
+	//agentDNSRefreshInterval indicates interval (in seconds) of resolving SNMP agent host name in SNMP agent configuration
+	agentDNSRefreshInterval = "dns_refresh_interval"
+
+	//agentCaptureMode indicates mode of capture of exchanges with SNMP agent (record or replay) in SNMP agent configuration
+	agentCaptureMode = "capture_mode"
+
+	//agentCaptureFile indicates path to capture file in SNMP agent configuration
+	agentCaptureFile = "capture_file"
+
+	//CaptureModeRecord option in capture mode, exchanges with SNMP agent are appended to capture file
+	CaptureModeRecord = "record"
+
+	//CaptureModeReplay option in capture mode, exchanges with SNMP agent are served from capture file instead of network
+	CaptureModeReplay = "replay"
+
 	//metricNamespace indicates metric namespace
 	metricNamespace = "namespace"
 
@@ -110,6 +185,30 @@ const (
 	//metricScale indicates scale value which can be used to multiplication of metric value
 	metricScale = "scale"
 
+	//metricTags indicates tags which are added to metric
+	metricTags = "tags"
+
+	//metricDivideByOid indicates column which value divides metric value
+	metricDivideByOid = "divide_by_OID"
+
+	//metricDivideByFallbackOid indicates column which value divides metric value if divisor is not available in divide_by_OID column
+	metricDivideByFallbackOid = "divide_by_fallback_OID"
+
+	//metricMultiplyByOid indicates column which value multiplies metric value
+	metricMultiplyByOid = "multiply_by_OID"
+
+	//metricScaleOid indicates column which contains data scale of metric value
+	metricScaleOid = "scale_OID"
+
+	//metricPrecisionOid indicates column which contains precision of metric value
+	metricPrecisionOid = "precision_OID"
+
+	//metricSubtypeOid indicates column which contains subtype of metric value
+	metricSubtypeOid = "subtype_OID"
+
+	//metricChange indicates that metric is returned on change of value
+	metricChange = "change"
+
 	//snmpv1 name of SNMP v1 in configuration
 	snmpv1 = "v1"
 
@@ -119,36 +218,91 @@ const (
 	//snmpv3 symbol of SNMP v3 in configuration
 	snmpv3 = "v3"
 
+	//securityLevelNoAuthNoPriv SNMP v3 security level without authentication and privacy
+	securityLevelNoAuthNoPriv = "NoAuthNoPriv"
+
+	//securityLevelAuthNoPriv SNMP v3 security level with authentication and without privacy
+	securityLevelAuthNoPriv = "AuthNoPriv"
+
+	//securityLevelAuthPriv SNMP v3 security level with authentication and privacy
+	securityLevelAuthPriv = "AuthPriv"
+
+	//minPassphraseLength minimal length of SNMP v3 pass phrases (RFC 3414)
+	minPassphraseLength = 8
+
 	//defaultRetries default number of connection retries
 	defaultRetries = 1
 
 	//defaultTimeout timeout for network connection
 	defaultTimeout = 5
 
+	//defaultDNSRefreshInterval default interval (in seconds) of resolving SNMP agent host name
+	defaultDNSRefreshInterval = 300
+
+	//ParameterString type of configuration parameter with string value
+	ParameterString = "string"
+
+	//ParameterInt type of configuration parameter with integer value
+	ParameterInt = "int"
+
 	//missingRequiredParameter error message for missing required parameter
 	missingRequiredParameter = "Missing required parameter in configuration (%s)"
 
 	//incorrectValueOfParameter error message for incorrect value of parameter
 	incorrectValueOfParameter = "Incorrect value of parameter (%s), possible options: %v"
+
+	//tooShortPassphrase error message for SNMP v3 pass phrase which is shorter than required
+	tooShortPassphrase = "Incorrect value of parameter (%s), pass phrase must be at least %d characters long"
 )
 
+//ConfigParameter describes parameter of plugin configuration, it is used to declare config policy of plugin,
+//conditions which cannot be expressed in config policy are checked by ValidateConfigParameters
+type ConfigParameter struct {
+	Name     string
+	Type     string
+	Required bool
+
+	//OptionalWith name of parameter which makes required parameter optional (e.g. address of SNMP agent is not set
+	//if discovery is configured), such parameter is declared as optional in config policy
+	OptionalWith string
+
+	//Default default value of parameter (string or int64), nil if parameter has no default value
+	Default interface{}
+
+	//Min and Max limits of value of int parameter, nil if value is not limited
+	Min *int64
+	Max *int64
+
+	//Options allowed values of string parameter, empty if any value is allowed
+	Options []interface{}
+}
+
 type SnmpAgent struct {
-	Name             string `mapstructure:"snmp_agent_name"`
-	SnmpVersion      string `mapstructure:"snmp_version"`
-	Address          string `mapstructure:"snmp_agent_address"`
-	Community        string `mapstructure:"community"`
-	Network          string `mapstructure:"network"`
-	UserName         string `mapstructure:"user_name"`
-	SecurityLevel    string `mapstructure:"security_level"`
-	AuthPassword     string `mapstructure:"auth_password"`
-	AuthProtocol     string `mapstructure:"auth_protocol"`
-	PrivPassword     string `mapstructure:"priv_password"`
-	PrivProtocol     string `mapstructure:"priv_protocol"`
-	SecurityEngineId string `mapstructure:"security_engine_id"`
-	ContextEngineId  string `mapstructure:"context_engine_id"`
-	ContextName      string `mapstructure:"context_name"`
-	Retries          uint   `mapstructure:"retries"`
-	Timeout          int    `mapstructure:"timeout"`
+	Name                  string `mapstructure:"snmp_agent_name"`
+	SnmpVersion           string `mapstructure:"snmp_version"`
+	Address               string `mapstructure:"snmp_agent_address"`
+	Community             string `mapstructure:"community"`
+	Network               string `mapstructure:"network"`
+	UserName              string `mapstructure:"user_name"`
+	SecurityLevel         string `mapstructure:"security_level"`
+	AuthPassword          string `mapstructure:"auth_password"`
+	AuthProtocol          string `mapstructure:"auth_protocol"`
+	PrivPassword          string `mapstructure:"priv_password"`
+	PrivProtocol          string `mapstructure:"priv_protocol"`
+	SecurityEngineId      string `mapstructure:"security_engine_id"`
+	ContextEngineId       string `mapstructure:"context_engine_id"`
+	ContextName           string `mapstructure:"context_name"`
+	Retries               uint   `mapstructure:"retries"`
+	Timeout               int    `mapstructure:"timeout"`
+	CredentialsFile       string `mapstructure:"credentials_file"`
+	CredentialsPassphrase string `mapstructure:"credentials_passphrase"`
+	CredentialSet         string `mapstructure:"credential_set"`
+	DNSRefreshInterval    int    `mapstructure:"dns_refresh_interval"`
+	CaptureMode           string `mapstructure:"capture_mode"`
+	CaptureFile           string `mapstructure:"capture_file"`
+
+	//CredentialSets configurations for credential sets which are probed in order, empty if credential sets are not configured
+	CredentialSets []SnmpAgent `mapstructure:"-"`
 }
 
 type Namespace struct {
@@ -156,9 +310,49 @@ type Namespace struct {
 	Name        string `json:"name"`
 	String      string `json:"string"`
 	OidPart     uint   `json:"oid_part"`
+	Encoding    string `json:"encoding"`
 	Oid         string `json:"OID"`
+	Join        string `json:"join"`
+	IndexOid    string `json:"index_OID"`
 	Description string `json:"description"`
-	Values      []string
+
+	//CacheTTL time (in seconds) for which namespace element read from SNMP agent is cached, 0 means no caching
+	CacheTTL uint `json:"cache_ttl"`
+
+	//CacheInvalidateOid OID (e.g. ifTableLastChange) which change invalidates cached namespace element
+	CacheInvalidateOid string `json:"cache_invalidate_OID"`
+
+	//FallbackOid OID which is read if OID is not available in SNMP agent (e.g. ifDescr for ifName)
+	FallbackOid string `json:"fallback_OID"`
+
+	//ValueMap maps values read from SNMP agent or decoded from index to namespace elements, values which are not mapped are not changed
+	ValueMap map[string]string `json:"value_map"`
+
+	//SubtypeOid column with the same index which contains subtype of value, it is used with join
+	SubtypeOid string `json:"subtype_OID"`
+
+	//SubtypeEncoding maps subtypes to encodings of value, values of not mapped subtypes are not decoded
+	SubtypeEncoding map[string]string `json:"subtype_encoding"`
+}
+
+type Tag struct {
+	Name     string `json:"name"`
+	Oid      string `json:"OID"`
+	Join     string `json:"join"`
+	IndexOid string `json:"index_OID"`
+	OidPart  uint   `json:"oid_part"`
+
+	CacheTTL           uint   `json:"cache_ttl"`
+	CacheInvalidateOid string `json:"cache_invalidate_OID"`
+
+	//ValueMap maps values read from SNMP agent to labels (e.g. enumeration or OID of type), values which are not mapped are not changed
+	ValueMap map[string]string `json:"value_map"`
+
+	//SubtypeOid column with the same index which contains subtype of value (e.g. lldpRemChassisIdSubtype), it is used with join
+	SubtypeOid string `json:"subtype_OID"`
+
+	//SubtypeEncoding maps subtypes to encodings of value, values of not mapped subtypes are not decoded
+	SubtypeEncoding map[string]string `json:"subtype_encoding"`
 }
 
 type Metric struct {
@@ -169,6 +363,51 @@ type Metric struct {
 	Description string      `json:"description"`
 	Shift       float64     `json:"shift"`
 	Scale       float64     `json:"scale"`
+	Tags        []Tag       `json:"tags"`
+
+	//FallbackOid OID which is read if OID is not available in SNMP agent (e.g. 32-bit counter for 64-bit counter)
+	FallbackOid string `json:"fallback_OID"`
+
+	//Rate indicates that per second rate of change of metric value is returned instead of value
+	Rate bool `json:"rate"`
+
+	//DivideByOid column of table with the same index which value divides metric value (e.g. interface speed for utilization)
+	DivideByOid string `json:"divide_by_OID"`
+
+	//DivideByFallbackOid column of table with the same index which value divides metric value if divisor is not available
+	//in DivideByOid column or it is not greater than 0 (e.g. ifSpeed for ifHighSpeed)
+	DivideByFallbackOid string `json:"divide_by_fallback_OID"`
+
+	//DivideByFallbackScale value by which divisor read from DivideByFallbackOid column is multiplied to get unit of DivideByOid column
+	//(e.g. 0.000001 to convert bit/s of ifSpeed into Mbit/s of ifHighSpeed)
+	DivideByFallbackScale float64 `json:"divide_by_fallback_scale"`
+
+	//MultiplyByOid column of table with the same index which value multiplies metric value (e.g. size of allocation unit)
+	MultiplyByOid string `json:"multiply_by_OID"`
+
+	//ScaleOid column of table with the same index which contains data scale of metric value (e.g. entPhySensorScale)
+	ScaleOid string `json:"scale_OID"`
+
+	//PrecisionOid column of table with the same index which contains number of decimal places of metric value (e.g. entPhySensorPrecision)
+	PrecisionOid string `json:"precision_OID"`
+
+	//SubtypeOid column of table with the same index which contains subtype of metric value (e.g. lldpRemChassisIdSubtype)
+	SubtypeOid string `json:"subtype_OID"`
+
+	//SubtypeEncoding maps subtypes to encodings of metric value, values of not mapped subtypes are not decoded
+	SubtypeEncoding map[string]string `json:"subtype_encoding"`
+
+	//ValueMap maps metric values to labels (e.g. states), values which are not mapped are not changed
+	ValueMap map[string]string `json:"value_map"`
+
+	//Change indicates that metric is returned only if its value differs from value in previous collection (e.g. transition of state)
+	Change bool `json:"change"`
+
+	//SkipValues values which are not returned as metric values, they are compared with values read from SNMP agent (e.g. special values -2 and -3 of level)
+	SkipValues []string `json:"skip_values"`
+
+	//Profile name of built-in profile which defines metric, it is empty for metrics defined in setfile
+	Profile string `json:"-"`
 }
 
 type Metrics []Metric
@@ -180,10 +419,35 @@ type reader interface {
 }
 
 var (
-	//AgentConfigParameters slice of agent configuration parameters
-	SnmpAgentConfigParameters = []string{agentName, agentAddress, agentSnmpVersion, agentCommunity, agentNetwork,
-		agentUserName, agentSecurityLevel, agentAuthPassword, agentAuthProtocol, agentPrivPassword,
-		agentPrivProtocol, agentSecurityEngineId, agentContextEngineID, agentContextName, agentRetries, agentTimeout}
+	//SnmpAgentConfigParameters slice of agent configuration parameters, parameters which are required only for some
+	//SNMP versions, security levels or without credential sets are declared as optional and validated when configuration is read
+	SnmpAgentConfigParameters = []ConfigParameter{
+		{Name: agentName, Type: ParameterString},
+		{Name: agentAddress, Type: ParameterString, Required: true, OptionalWith: discoveryRanges},
+		{Name: agentSnmpVersion, Type: ParameterString, Options: snmpVersionOptions},
+		{Name: agentCommunity, Type: ParameterString},
+		{Name: agentNetwork, Type: ParameterString, Default: defaultNetwork, Options: networkOptions},
+		{Name: agentUserName, Type: ParameterString},
+		{Name: agentSecurityLevel, Type: ParameterString, Options: securityLevelOptions},
+		{Name: agentAuthPassword, Type: ParameterString},
+		{Name: agentAuthProtocol, Type: ParameterString, Options: authProtocolOptions},
+		{Name: agentPrivPassword, Type: ParameterString},
+		{Name: agentPrivProtocol, Type: ParameterString, Options: privProtocolOptions},
+		{Name: agentSecurityEngineId, Type: ParameterString},
+		{Name: agentContextEngineID, Type: ParameterString},
+		{Name: agentContextName, Type: ParameterString},
+		{Name: agentRetries, Type: ParameterInt, Default: int64(defaultRetries), Min: limit(0)},
+		{Name: agentTimeout, Type: ParameterInt, Default: int64(defaultTimeout), Min: limit(1)},
+		{Name: agentCredentialsFile, Type: ParameterString},
+		{Name: agentCredentialsPassphrase, Type: ParameterString},
+		{Name: agentCredentialSets, Type: ParameterString},
+		{Name: agentDNSRefreshInterval, Type: ParameterInt, Default: int64(defaultDNSRefreshInterval), Min: limit(1)},
+		{Name: agentCaptureMode, Type: ParameterString, Options: captureModeOptions},
+		{Name: agentCaptureFile, Type: ParameterString},
+	}
+
+	//captureModeOptions slice of options for capture mode parameter
+	captureModeOptions = []interface{}{CaptureModeRecord, CaptureModeReplay}
 
 	//modeOptions slice of options for mode parameter
 	modeOptions = []interface{}{ModeSingle, ModeWalk, ModeTable}
@@ -192,7 +456,7 @@ var (
 	snmpVersionOptions = []interface{}{snmpv1, snmpv2, snmpv3}
 
 	//securityLevelOptions slice of options for SNMP security level
-	securityLevelOptions = []interface{}{"NoAuthNoPriv", "AuthNoPriv", "AuthPriv"}
+	securityLevelOptions = []interface{}{securityLevelNoAuthNoPriv, securityLevelAuthNoPriv, securityLevelAuthPriv}
 
 	//authProtocolOptions slice of options for SNMP authentication protocol
 	authProtocolOptions = []interface{}{"MD5", "SHA"}
@@ -200,6 +464,16 @@ var (
 	//privProtocolOptions slice of options for SNMP privacy protocol
 	privProtocolOptions = []interface{}{"DES", "AES"}
 
+	//indexEncodingOptions slice of options for encoding of index namespace element
+	indexEncodingOptions = []interface{}{IndexEncodingInteger, IndexEncodingIPv4, IndexEncodingIPv6, IndexEncodingMAC,
+		IndexEncodingInetAddress, IndexEncodingString, IndexEncodingImpliedString, IndexEncodingOid, IndexEncodingImpliedOid}
+
+	//joinOptions slice of options for join of namespace element or tag
+	joinOptions = []interface{}{JoinIndex, JoinPointer}
+
+	//valueEncodingOptions slice of options for encoding of value which depends on subtype
+	valueEncodingOptions = []interface{}{ValueEncodingMAC, ValueEncodingIP, ValueEncodingNetworkAddress, ValueEncodingString, ValueEncodingHex}
+
 	//cfgReader provides possibility to read metric configuration from file or from different source
 	cfgReader = reader(&cfgReaderType{})
 )
@@ -208,14 +482,77 @@ func (r *cfgReaderType) ReadFile(s string) ([]byte, error) {
 	return ioutil.ReadFile(s)
 }
 
-//GetMetricsConfig decodes and validates configuration of SNMP agent
+//limit returns pointer to limit of value of int parameter
+func limit(value int64) *int64 {
+	return &value
+}
+
+//GetSnmpAgentConfig decodes and validates configuration of SNMP agent,
+//if credential sets are configured then configuration for the first of them is returned and configurations for all of them are available in CredentialSets
 func GetSnmpAgentConfig(configMap plugin.Config) (SnmpAgent, error) {
+	if err := ValidateConfigParameters(configMap); err != nil {
+		log.WithFields(log.Fields{"agent_config": configMap}).Warn(err)
+		return SnmpAgent{}, err
+	}
+
+	credentialSets, err := getCredentialSets(configMap)
+	if err != nil {
+		log.WithFields(log.Fields{"parameter": agentCredentialSets}).Warn(err)
+		return SnmpAgent{}, err
+	}
+
+	if len(credentialSets) == 0 {
+		return getSnmpAgentConfig(configMap)
+	}
+
+	configs := []SnmpAgent{}
+	for _, credentialSet := range credentialSets {
+		config, err := getSnmpAgentConfig(credentialSet)
+		if err != nil {
+			return config, fmt.Errorf("Incorrect configuration of credential set (%v): %v", credentialSet[agentCredentialSet], err)
+		}
+		configs = append(configs, config)
+	}
+
+	config := configs[0]
+	config.CredentialSets = configs
+	return config, nil
+}
+
+//ValidateConfigParameters checks declared parameters which cannot be validated by config policy: required parameters
+//which are optional with another parameter and allowed values of string parameters
+func ValidateConfigParameters(configMap plugin.Config) error {
+	for _, parameters := range [][]ConfigParameter{SnmpAgentConfigParameters, DiscoveryConfigParameters} {
+		for _, parameter := range parameters {
+			value, ok := configMap[parameter.Name]
+			if !ok || value == "" {
+				if parameter.Required && !(parameter.OptionalWith != "" && configMap[parameter.OptionalWith] != nil) {
+					return fmt.Errorf(missingRequiredParameter, parameter.Name)
+				}
+				continue
+			}
+			if len(parameter.Options) > 0 && !checkPossibleOptions(value, parameter.Options) {
+				return fmt.Errorf(incorrectValueOfParameter, parameter.Name, parameter.Options)
+			}
+		}
+	}
+	return nil
+}
+
+//getSnmpAgentConfig decodes, resolves credentials and validates configuration of SNMP agent
+func getSnmpAgentConfig(configMap plugin.Config) (SnmpAgent, error) {
 	config, err := decodeSnmpAgentConfig(configMap)
 	if err != nil {
 		return config, err
 	}
 
-	err = validateSnmpAgentConfig(config)
+	err = resolveCredentials(&config)
+	if err != nil {
+		log.WithFields(log.Fields{"agent_config": config}).Warn(err)
+		return config, err
+	}
+
+	err = validateSnmpAgentConfig(&config)
 	if err != nil {
 		return config, err
 	}
@@ -238,6 +575,21 @@ func GetMetricsConfig(setFilePath string) (Metrics, error) {
 	return config, nil
 }
 
+//ParseMetricsConfig decodes and validates configuration of metrics which is given as content of setfile (e.g. embedded profile)
+func ParseMetricsConfig(content []byte) (Metrics, error) {
+	config, err := decodeMetricsConfig(content, map[string]interface{}{"setfile_size": len(content)})
+	if err != nil {
+		return config, err
+	}
+
+	err = validateMetricConfig(config)
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
 //decodeSnmpAgentConfig decodes configuration of SNMP agent into structure
 func decodeSnmpAgentConfig(config plugin.Config) (SnmpAgent, error) {
 	var snmpAgentConfig SnmpAgent
@@ -247,13 +599,18 @@ func decodeSnmpAgentConfig(config plugin.Config) (SnmpAgent, error) {
 		log.WithFields(logFields).Warn(err)
 		return snmpAgentConfig, err
 	}
+
+	//explicit 0 disables retries, so default is set only if parameter is not configured
+	if _, ok := config[agentRetries]; !ok {
+		snmpAgentConfig.Retries = defaultRetries
+	}
 	return snmpAgentConfig, nil
 }
 
-//validateSnmpAgentConfig validates configuration of SNMP agent
-func validateSnmpAgentConfig(config SnmpAgent) error {
+//validateSnmpAgentConfig validates configuration of SNMP agent and sets default values of optional parameters
+func validateSnmpAgentConfig(config *SnmpAgent) error {
 	logFields := map[string]interface{}{}
-	logFields["agent_config"] = config
+	logFields["agent_config"] = *config
 
 	if !checkSetParameter(config.Address) {
 		logFields["parameter"] = agentAddress
@@ -262,6 +619,25 @@ func validateSnmpAgentConfig(config SnmpAgent) error {
 		return err
 	}
 
+	if !checkSetParameter(config.Network) {
+		config.Network = defaultNetwork
+	}
+
+	if !checkPossibleOptions(config.Network, networkOptions) {
+		logFields["parameter"] = agentNetwork
+		err := fmt.Errorf(incorrectValueOfParameter, config.Network, networkOptions)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	address, err := normalizeAddress(config.Address, config.Network)
+	if err != nil {
+		logFields["parameter"] = agentAddress
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+	config.Address = address
+
 	if !checkSetParameter(config.SnmpVersion) {
 		logFields["parameter"] = agentSnmpVersion
 		err := fmt.Errorf(missingRequiredParameter, agentSnmpVersion)
@@ -279,65 +655,137 @@ func validateSnmpAgentConfig(config SnmpAgent) error {
 	if config.SnmpVersion == snmpv1 || config.SnmpVersion == snmpv2 {
 		//check required fields for SNMP v1 and SNMP v2c
 		if !checkSetParameter(config.Community) {
-			logFields["paramgiteter"] = agentCommunity
+			logFields["parameter"] = agentCommunity
 			err := fmt.Errorf(missingRequiredParameter, agentCommunity)
 			log.WithFields(logFields).Warn(err)
 			return err
 		}
 	} else {
 		//check required fields for SNMP v3
-		if !checkSetParameter(config.SecurityLevel) {
-			logFields["parameter"] = agentSecurityLevel
-			err := fmt.Errorf(missingRequiredParameter, agentSecurityLevel)
-			log.WithFields(logFields).Warn(err)
+		if err := validateSnmpV3AgentConfig(config, logFields); err != nil {
 			return err
 		}
+	}
 
-		if !checkPossibleOptions(config.SecurityLevel, securityLevelOptions) {
-			logFields["parameter"] = agentSecurityLevel
-			err := fmt.Errorf(incorrectValueOfParameter, config.SecurityLevel, securityLevelOptions)
+	if checkSetParameter(config.CaptureMode) {
+		if !checkPossibleOptions(config.CaptureMode, captureModeOptions) {
+			logFields["parameter"] = agentCaptureMode
+			err := fmt.Errorf(incorrectValueOfParameter, config.CaptureMode, captureModeOptions)
 			log.WithFields(logFields).Warn(err)
 			return err
 		}
 
-		if !checkSetParameter(config.AuthProtocol) {
-			logFields["parameter"] = agentAuthProtocol
-			err := fmt.Errorf(missingRequiredParameter, agentAuthProtocol)
+		if !checkSetParameter(config.CaptureFile) {
+			logFields["parameter"] = agentCaptureFile
+			err := fmt.Errorf(missingRequiredParameter, agentCaptureFile)
 			log.WithFields(logFields).Warn(err)
 			return err
 		}
+	}
 
-		if !checkPossibleOptions(config.AuthProtocol, authProtocolOptions) {
-			logFields["parameter"] = agentAuthProtocol
-			err := fmt.Errorf(incorrectValueOfParameter, config.AuthProtocol, authProtocolOptions)
-			log.WithFields(logFields).Warn(err)
-			return err
-		}
+	//set default values, default number of retries is set when configuration is decoded because 0 retries is valid
+	if !checkSetParameter(config.Timeout) {
+		config.Timeout = defaultTimeout
+	}
 
-		if !checkSetParameter(config.PrivProtocol) {
-			logFields["parameter"] = agentPrivProtocol
-			err := fmt.Errorf(missingRequiredParameter, agentPrivProtocol)
-			log.WithFields(logFields).Warn(err)
-			return err
-		}
+	if !checkSetParameter(config.DNSRefreshInterval) {
+		config.DNSRefreshInterval = defaultDNSRefreshInterval
+	}
 
-		if !checkPossibleOptions(config.PrivProtocol, privProtocolOptions) {
-			logFields["parameter"] = agentPrivProtocol
-			err := fmt.Errorf(incorrectValueOfParameter, config.PrivProtocol, privProtocolOptions)
-			log.WithFields(logFields).Warn(err)
-			return err
-		}
+	return nil
+}
 
-		//set default values
-		if !checkSetParameter(config.Retries) {
-			config.Retries = defaultRetries
-		}
+//validateSnmpV3AgentConfig validates SNMP v3 parameters of SNMP agent configuration,
+//parameters of authentication and privacy protocols are required only if chosen security level uses them
+func validateSnmpV3AgentConfig(config *SnmpAgent, logFields map[string]interface{}) error {
+	if !checkSetParameter(config.UserName) {
+		logFields["parameter"] = agentUserName
+		err := fmt.Errorf(missingRequiredParameter, agentUserName)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
 
-		if !checkSetParameter(config.Timeout) {
-			config.Timeout = defaultTimeout
-		}
+	if !checkSetParameter(config.SecurityLevel) {
+		logFields["parameter"] = agentSecurityLevel
+		err := fmt.Errorf(missingRequiredParameter, agentSecurityLevel)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
 
+	if !checkPossibleOptions(config.SecurityLevel, securityLevelOptions) {
+		logFields["parameter"] = agentSecurityLevel
+		err := fmt.Errorf(incorrectValueOfParameter, config.SecurityLevel, securityLevelOptions)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	//protocols are checked if they are set, even if security level does not use them
+	if checkSetParameter(config.AuthProtocol) && !checkPossibleOptions(config.AuthProtocol, authProtocolOptions) {
+		logFields["parameter"] = agentAuthProtocol
+		err := fmt.Errorf(incorrectValueOfParameter, config.AuthProtocol, authProtocolOptions)
+		log.WithFields(logFields).Warn(err)
+		return err
 	}
+
+	if checkSetParameter(config.PrivProtocol) && !checkPossibleOptions(config.PrivProtocol, privProtocolOptions) {
+		logFields["parameter"] = agentPrivProtocol
+		err := fmt.Errorf(incorrectValueOfParameter, config.PrivProtocol, privProtocolOptions)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	if config.SecurityLevel == securityLevelNoAuthNoPriv {
+		return nil
+	}
+
+	//check required fields for AuthNoPriv and AuthPriv security levels
+	if !checkSetParameter(config.AuthProtocol) {
+		logFields["parameter"] = agentAuthProtocol
+		err := fmt.Errorf(missingRequiredParameter, agentAuthProtocol)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	if !checkSetParameter(config.AuthPassword) {
+		logFields["parameter"] = agentAuthPassword
+		err := fmt.Errorf(missingRequiredParameter, agentAuthPassword)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	if len(config.AuthPassword) < minPassphraseLength {
+		logFields["parameter"] = agentAuthPassword
+		err := fmt.Errorf(tooShortPassphrase, agentAuthPassword, minPassphraseLength)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	if config.SecurityLevel == securityLevelAuthNoPriv {
+		return nil
+	}
+
+	//check required fields for AuthPriv security level
+	if !checkSetParameter(config.PrivProtocol) {
+		logFields["parameter"] = agentPrivProtocol
+		err := fmt.Errorf(missingRequiredParameter, agentPrivProtocol)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	if !checkSetParameter(config.PrivPassword) {
+		logFields["parameter"] = agentPrivPassword
+		err := fmt.Errorf(missingRequiredParameter, agentPrivPassword)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
+	if len(config.PrivPassword) < minPassphraseLength {
+		logFields["parameter"] = agentPrivPassword
+		err := fmt.Errorf(tooShortPassphrase, agentPrivPassword, minPassphraseLength)
+		log.WithFields(logFields).Warn(err)
+		return err
+	}
+
 	return nil
 }
 
@@ -348,19 +796,26 @@ func readMetricConfigFile(setFilePath string) (Metrics, error) {
 	logFields["setfile_path"] = setFilePath
 
 	setFileContent, err := cfgReader.ReadFile(setFilePath)
-	logFields["setfile_content"] = setFileContent
+	logFields["setfile_size"] = len(setFileContent)
 	if err != nil {
 		log.WithFields(logFields).Warn(err)
 		return config, err
 	}
 
+	return decodeMetricsConfig(setFileContent, logFields)
+}
+
+//decodeMetricsConfig decodes metric configuration to structures
+func decodeMetricsConfig(setFileContent []byte, logFields map[string]interface{}) (Metrics, error) {
+	var config Metrics
+
 	if len(setFileContent) == 0 {
 		err := fmt.Errorf("Metrics configuration file is empty")
 		log.WithFields(logFields).Warn(err)
 		return config, err
 	}
 
-	err = json.Unmarshal(setFileContent, &config)
+	err := json.Unmarshal(setFileContent, &config)
 	if err != nil {
 		err := fmt.Errorf("Settings file cannot be unmarshalled, err: %s", err)
 		log.WithFields(logFields).Warn(err)
@@ -420,6 +875,59 @@ func validateMetricConfig(metricConfigs Metrics) error {
 			return err
 		}
 
+		//operands are joined by index of table, so they cannot be used in single mode
+		operands := map[string]string{
+			metricDivideByOid:         metricConfigs[i].DivideByOid,
+			metricDivideByFallbackOid: metricConfigs[i].DivideByFallbackOid,
+			metricMultiplyByOid:       metricConfigs[i].MultiplyByOid,
+			metricScaleOid:            metricConfigs[i].ScaleOid,
+			metricPrecisionOid:        metricConfigs[i].PrecisionOid,
+			metricSubtypeOid:          metricConfigs[i].SubtypeOid,
+		}
+		for parameter, oid := range operands {
+			if checkSetParameter(oid) && metricConfigs[i].Mode == ModeSingle {
+				logFields["parameter"] = parameter
+				err := fmt.Errorf("Parameter `%s` cannot be used in `%s` mode", parameter, ModeSingle)
+				log.WithFields(logFields).Warn(err)
+				return err
+			}
+		}
+
+		//rate of change of value is not available for metric which is returned on change of value
+		if metricConfigs[i].Change && metricConfigs[i].Rate {
+			logFields["parameter"] = metricChange
+			err := fmt.Errorf("Parameter `change` cannot be used together with `rate`")
+			log.WithFields(logFields).Warn(err)
+			return err
+		}
+
+		//fallback divisor is read only if divisor is configured
+		if checkSetParameter(metricConfigs[i].DivideByFallbackOid) && !checkSetParameter(metricConfigs[i].DivideByOid) {
+			logFields["parameter"] = metricDivideByFallbackOid
+			err := fmt.Errorf("Parameter `%s` requires `%s`", metricDivideByFallbackOid, metricDivideByOid)
+			log.WithFields(logFields).Warn(err)
+			return err
+		}
+
+		//set default value for scale of fallback divisor if it is not configured
+		if !checkSetParameter(metricConfigs[i].DivideByFallbackScale) {
+			metricConfigs[i].DivideByFallbackScale = 1.0
+		}
+
+		//subtype of metric value is read from the same table
+		if err := validateSubtype(metricConfigs[i].SubtypeOid, metricConfigs[i].SubtypeEncoding, JoinIndex); err != nil {
+			logFields["parameter"] = metricSubtypeOid
+			log.WithFields(logFields).Warn(err)
+			return err
+		}
+
+		//validate tags configuration
+		if err := validateTags(metricConfigs[i].Tags); err != nil {
+			logFields["parameter"] = metricTags
+			log.WithFields(logFields).Warn(err)
+			return err
+		}
+
 		//set default value for scale if scale is not configured
 		if !checkSetParameter(metricConfigs[i].Scale) {
 			metricConfigs[i].Scale = 1.0
@@ -435,6 +943,18 @@ func validateNamespace(namespaceConfig []Namespace) error {
 	}
 
 	for _, nsCfg := range namespaceConfig {
+		if err := validateCache(nsCfg.CacheTTL, nsCfg.CacheInvalidateOid); err != nil {
+			return err
+		}
+
+		if checkSetParameter(nsCfg.CacheTTL) && nsCfg.Source != NsSourceSNMP {
+			return fmt.Errorf("Parameter `cache_ttl` can be set only for namespace element with `source` set to `%s`", NsSourceSNMP)
+		}
+
+		if checkSetParameter(nsCfg.FallbackOid) && nsCfg.Source != NsSourceSNMP {
+			return fmt.Errorf("Parameter `fallback_OID` can be set only for namespace element with `source` set to `%s`", NsSourceSNMP)
+		}
+
 		switch nsCfg.Source {
 		case NsSourceString:
 			//check required  parameter for source set to string
@@ -454,12 +974,27 @@ func validateNamespace(namespaceConfig []Namespace) error {
 			if !checkSetParameter(nsCfg.Description) {
 				return fmt.Errorf("Cannot find `description` parameter in configuration namespace element")
 			}
+
+			if checkSetParameter(nsCfg.Join) {
+				if err := validateJoin(nsCfg.Join, nsCfg.IndexOid); err != nil {
+					return err
+				}
+			}
+
+			if err := validateSubtype(nsCfg.SubtypeOid, nsCfg.SubtypeEncoding, nsCfg.Join); err != nil {
+				return err
+			}
 		case NsSourceIndex:
 			//check required  parameter for source set to index
 			if !checkSetParameter(nsCfg.OidPart) {
 				return fmt.Errorf("Cannot find `oid_part` parameter in configuration namespace element")
 			}
 
+			if checkSetParameter(nsCfg.Encoding) && !checkPossibleOptions(nsCfg.Encoding, indexEncodingOptions) {
+				return fmt.Errorf("Incorrect value of `encoding` (%s) in namespace configuration, possible options: %v",
+					nsCfg.Encoding, indexEncodingOptions)
+			}
+
 			if !checkSetParameter(nsCfg.Name) {
 				return fmt.Errorf("Cannot find `name` parameter in configuration namespace element")
 			}
@@ -475,6 +1010,76 @@ func validateNamespace(namespaceConfig []Namespace) error {
 	return nil
 }
 
+//validateTags validates configuration of metric tags
+func validateTags(tagsConfig []Tag) error {
+	for _, tagCfg := range tagsConfig {
+		if !checkSetParameter(tagCfg.Name) {
+			return fmt.Errorf("Cannot find `name` parameter in configuration of tag")
+		}
+
+		if !checkSetParameter(tagCfg.Oid) {
+			return fmt.Errorf("Cannot find `OID` parameter in configuration of tag (%s)", tagCfg.Name)
+		}
+
+		if err := validateCache(tagCfg.CacheTTL, tagCfg.CacheInvalidateOid); err != nil {
+			return err
+		}
+
+		//tag without join is read from scalar OID
+		if checkSetParameter(tagCfg.Join) {
+			if err := validateJoin(tagCfg.Join, tagCfg.IndexOid); err != nil {
+				return err
+			}
+		}
+
+		if err := validateSubtype(tagCfg.SubtypeOid, tagCfg.SubtypeEncoding, tagCfg.Join); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//validateJoin validates join with column of other table
+func validateJoin(join string, indexOid string) error {
+	if !checkPossibleOptions(join, joinOptions) {
+		return fmt.Errorf("Incorrect value of `join` (%s), possible options: %v", join, joinOptions)
+	}
+
+	if join == JoinPointer && !checkSetParameter(indexOid) {
+		return fmt.Errorf("Cannot find `index_OID` parameter required for `join` set to `%s`", JoinPointer)
+	}
+	return nil
+}
+
+//validateSubtype validates decoding of value which depends on subtype, subtype is read from column with the same index as joined column
+func validateSubtype(subtypeOid string, subtypeEncoding map[string]string, join string) error {
+	if !checkSetParameter(subtypeOid) {
+		if len(subtypeEncoding) > 0 {
+			return fmt.Errorf("Parameter `subtype_encoding` requires `subtype_OID`")
+		}
+		return nil
+	}
+
+	if !checkSetParameter(join) {
+		return fmt.Errorf("Parameter `subtype_OID` requires `join`")
+	}
+
+	for subtype, encoding := range subtypeEncoding {
+		if !checkPossibleOptions(encoding, valueEncodingOptions) {
+			return fmt.Errorf("Incorrect encoding (%s) of subtype (%s) in `subtype_encoding`, possible options: %v", encoding, subtype, valueEncodingOptions)
+		}
+	}
+	return nil
+}
+
+//validateCache validates caching of namespace element or tag
+func validateCache(cacheTTL uint, cacheInvalidateOid string) error {
+	if checkSetParameter(cacheInvalidateOid) && !checkSetParameter(cacheTTL) {
+		return fmt.Errorf("Parameter `cache_invalidate_OID` requires `cache_ttl`")
+	}
+	return nil
+}
+
 //checkRequiredParam checks if required parameter is set
 func checkSetParameter(param interface{}) bool {
 	switch param.(type) {
@@ -24,8 +24,13 @@ package configReader
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
 
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -34,18 +39,32 @@ const (
 	CORRECT_METRIC_CONFIG_2
 	CORRECT_METRIC_CONFIG_3
 	CORRECT_METRIC_CONFIG_4
+	CORRECT_METRIC_CONFIG_5
+	CORRECT_METRIC_CONFIG_6
 	WRONG_METRIC_CONFIG_1
 	WRONG_METRIC_CONFIG_2
 	WRONG_METRIC_CONFIG_3
 	WRONG_METRIC_CONFIG_4
 	WRONG_METRIC_CONFIG_5
 	WRONG_METRIC_CONFIG_6
+	WRONG_METRIC_CONFIG_7
+	WRONG_METRIC_CONFIG_8
+	WRONG_METRIC_CONFIG_9
+	WRONG_METRIC_CONFIG_10
+	WRONG_METRIC_CONFIG_11
+	WRONG_METRIC_CONFIG_12
+	WRONG_METRIC_CONFIG_13
+	WRONG_METRIC_CONFIG_14
+	WRONG_METRIC_CONFIG_15
+	WRONG_METRIC_CONFIG_16
 	SETFILE_NOT_FOUND
 	EMPTY_SETFILE
 	WRONG_SETFILE
 	CORRECT_AGENT_CONFIG_1
 	CORRECT_AGENT_CONFIG_2
 	CORRECT_AGENT_CONFIG_3
+	CORRECT_AGENT_CONFIG_5
+	CORRECT_AGENT_CONFIG_6
 	WRONG_AGENT_CONFIG_1
 	WRONG_AGENT_CONFIG_2
 	WRONG_AGENT_CONFIG_3
@@ -60,6 +79,10 @@ const (
 	WRONG_AGENT_CONFIG_12
 	WRONG_AGENT_CONFIG_13
 	WRONG_AGENT_CONFIG_14
+	WRONG_AGENT_CONFIG_17
+	WRONG_AGENT_CONFIG_18
+	WRONG_AGENT_CONFIG_19
+	WRONG_AGENT_CONFIG_20
 	EMPTY_AGENT_CONFIG
 )
 
@@ -77,12 +100,24 @@ var metricsConfigsTestTable = map[int]metricsEntry{
 	CORRECT_METRIC_CONFIG_2: newMetricsConfig(json.Marshal(getCorrectConfig2())),
 	CORRECT_METRIC_CONFIG_3: newMetricsConfig(json.Marshal(getCorrectConfig3())),
 	CORRECT_METRIC_CONFIG_4: newMetricsConfig(json.Marshal(getCorrectConfig4())),
+	CORRECT_METRIC_CONFIG_5: newMetricsConfig(json.Marshal(getCorrectConfig5())),
+	CORRECT_METRIC_CONFIG_6: newMetricsConfig(json.Marshal(getCorrectConfig6())),
 	WRONG_METRIC_CONFIG_1:   newMetricsConfig(json.Marshal(getWrongConfig1())),
 	WRONG_METRIC_CONFIG_2:   newMetricsConfig(json.Marshal(getWrongConfig2())),
 	WRONG_METRIC_CONFIG_3:   newMetricsConfig(json.Marshal(getWrongConfig3())),
 	WRONG_METRIC_CONFIG_4:   newMetricsConfig(json.Marshal(getWrongConfig4())),
 	WRONG_METRIC_CONFIG_5:   newMetricsConfig(json.Marshal(getWrongConfig5())),
 	WRONG_METRIC_CONFIG_6:   newMetricsConfig(json.Marshal(getWrongConfig6())),
+	WRONG_METRIC_CONFIG_7:   newMetricsConfig(json.Marshal(getWrongConfig7())),
+	WRONG_METRIC_CONFIG_8:   newMetricsConfig(json.Marshal(getWrongConfig8())),
+	WRONG_METRIC_CONFIG_9:   newMetricsConfig(json.Marshal(getWrongConfig9())),
+	WRONG_METRIC_CONFIG_10:  newMetricsConfig(json.Marshal(getWrongConfig10())),
+	WRONG_METRIC_CONFIG_11:  newMetricsConfig(json.Marshal(getWrongConfig11())),
+	WRONG_METRIC_CONFIG_12:  newMetricsConfig(json.Marshal(getWrongConfig12())),
+	WRONG_METRIC_CONFIG_13:  newMetricsConfig(json.Marshal(getWrongConfig13())),
+	WRONG_METRIC_CONFIG_14:  newMetricsConfig(json.Marshal(getWrongConfig14())),
+	WRONG_METRIC_CONFIG_15:  newMetricsConfig(json.Marshal(getWrongConfig15())),
+	WRONG_METRIC_CONFIG_16:  newMetricsConfig(json.Marshal(getWrongConfig16())),
 	SETFILE_NOT_FOUND:       newMetricsConfig(nil, errors.New("Setfile not found")),
 	EMPTY_SETFILE:           newMetricsConfig(nil, nil),
 	WRONG_SETFILE:           newMetricsConfig(json.Marshal(map[string]int{"Foo": 1, "Bar": 2})),
@@ -92,6 +127,8 @@ var agentConfigsTestTable = map[int]map[string]interface{}{
 	CORRECT_AGENT_CONFIG_1: getCorrectAgentConfig1(),
 	CORRECT_AGENT_CONFIG_2: getCorrectAgentConfig2(),
 	CORRECT_AGENT_CONFIG_3: getCorrectAgentConfig3(),
+	CORRECT_AGENT_CONFIG_5: getCorrectAgentConfig5(),
+	CORRECT_AGENT_CONFIG_6: getCorrectAgentConfig6(),
 	WRONG_AGENT_CONFIG_1:   getWrongAgentConfig1(),
 	WRONG_AGENT_CONFIG_2:   getWrongAgentConfig2(),
 	WRONG_AGENT_CONFIG_3:   getWrongAgentConfig3(),
@@ -106,6 +143,10 @@ var agentConfigsTestTable = map[int]map[string]interface{}{
 	WRONG_AGENT_CONFIG_12:  getWrongAgentConfig12(),
 	WRONG_AGENT_CONFIG_13:  getWrongAgentConfig13(),
 	WRONG_AGENT_CONFIG_14:  getWrongAgentConfig14(),
+	WRONG_AGENT_CONFIG_17:  getWrongAgentConfig17(),
+	WRONG_AGENT_CONFIG_18:  getWrongAgentConfig18(),
+	WRONG_AGENT_CONFIG_19:  getWrongAgentConfig19(),
+	WRONG_AGENT_CONFIG_20:  getWrongAgentConfig20(),
 	EMPTY_AGENT_CONFIG:     getWrongAgentConfig16(),
 }
 
@@ -144,6 +185,18 @@ func TestGetMetricsConfig(t *testing.T) {
 			So(serr, ShouldBeNil)
 		})
 
+		Convey("Testing CORRECT_METRIC_CONFIG_5", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[CORRECT_METRIC_CONFIG_5]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldBeNil)
+		})
+
+		Convey("Testing CORRECT_METRIC_CONFIG_6", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[CORRECT_METRIC_CONFIG_6]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldBeNil)
+		})
+
 		Convey("Testing WRONG_METRIC_CONFIG_1", func() {
 			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_1]}
 			_, serr := GetMetricsConfig("setfile.json")
@@ -180,6 +233,67 @@ func TestGetMetricsConfig(t *testing.T) {
 			So(serr, ShouldNotBeNil)
 		})
 
+		Convey("Testing WRONG_METRIC_CONFIG_7", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_7]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_8", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_8]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_9", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_9]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_10", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_10]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_11", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_11]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_12", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_12]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_13", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_13]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_14", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_14]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_15", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_15]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_METRIC_CONFIG_16", func() {
+			cfgReader = &mockReader{metricsConfigsTestTable[WRONG_METRIC_CONFIG_16]}
+			_, serr := GetMetricsConfig("setfile.json")
+			So(serr, ShouldNotBeNil)
+			So(serr.Error(), ShouldContainSubstring, "divide_by_fallback_OID")
+		})
+
 		Convey("Testing SETFILE_NOT_FOUND", func() {
 			cfgReader = &mockReader{metricsConfigsTestTable[SETFILE_NOT_FOUND]}
 			_, serr := GetMetricsConfig("setfile.json")
@@ -218,6 +332,38 @@ func TestGetAgentConfig(t *testing.T) {
 			So(serr, ShouldBeNil)
 		})
 
+		Convey("Testing CORRECT_AGENT_CONFIG_5", func() {
+			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[CORRECT_AGENT_CONFIG_5])
+			So(serr, ShouldBeNil)
+		})
+
+		Convey("Testing CORRECT_AGENT_CONFIG_6", func() {
+			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[CORRECT_AGENT_CONFIG_6])
+			So(serr, ShouldBeNil)
+		})
+
+		Convey("Testing default values of retries and timeout", func() {
+			config, serr := GetSnmpAgentConfig(agentConfigsTestTable[CORRECT_AGENT_CONFIG_1])
+			So(serr, ShouldBeNil)
+			So(config.Retries, ShouldEqual, defaultRetries)
+			So(config.Timeout, ShouldEqual, defaultTimeout)
+		})
+
+		Convey("Testing configured values of retries and timeout", func() {
+			config, serr := GetSnmpAgentConfig(agentConfigsTestTable[CORRECT_AGENT_CONFIG_3])
+			So(serr, ShouldBeNil)
+			So(config.Retries, ShouldEqual, 3)
+			So(config.Timeout, ShouldEqual, 5)
+		})
+
+		Convey("Testing disabled retries", func() {
+			agentConfig := getCorrectAgentConfig3()
+			agentConfig["retries"] = int64(0)
+			config, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldBeNil)
+			So(config.Retries, ShouldEqual, 0)
+		})
+
 		Convey("Testing WRONG_AGENT_CONFIG_1", func() {
 			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[WRONG_AGENT_CONFIG_1])
 			So(serr, ShouldNotBeNil)
@@ -288,6 +434,26 @@ func TestGetAgentConfig(t *testing.T) {
 			So(serr, ShouldNotBeNil)
 		})
 
+		Convey("Testing WRONG_AGENT_CONFIG_17", func() {
+			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[WRONG_AGENT_CONFIG_17])
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_AGENT_CONFIG_18", func() {
+			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[WRONG_AGENT_CONFIG_18])
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_AGENT_CONFIG_19", func() {
+			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[WRONG_AGENT_CONFIG_19])
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing WRONG_AGENT_CONFIG_20", func() {
+			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[WRONG_AGENT_CONFIG_20])
+			So(serr, ShouldNotBeNil)
+		})
+
 		Convey("Testing EMPTY_AGENT_CONFIG", func() {
 			_, serr := GetSnmpAgentConfig(agentConfigsTestTable[EMPTY_AGENT_CONFIG])
 			So(serr, ShouldNotBeNil)
@@ -295,3 +461,432 @@ func TestGetAgentConfig(t *testing.T) {
 
 	})
 }
+
+func TestCredentials(t *testing.T) {
+	Convey("Testing credentials", t, func() {
+		cfgReader = &cfgReaderType{}
+
+		secretFile, err := ioutil.TempFile("", "snmp-secret")
+		So(err, ShouldBeNil)
+		defer os.Remove(secretFile.Name())
+		secretFile.WriteString("file-community\n")
+		secretFile.Close()
+
+		credentialsFile, err := ioutil.TempFile("", "snmp-credentials")
+		So(err, ShouldBeNil)
+		defer os.Remove(credentialsFile.Name())
+		credentialsFile.WriteString(encryptedCredentials)
+		credentialsFile.Close()
+
+		os.Setenv("SNMP_TEST_COMMUNITY", "env-community")
+		os.Setenv("SNMP_TEST_CREDENTIALS_PASSPHRASE", "testpassphrase")
+		defer os.Unsetenv("SNMP_TEST_COMMUNITY")
+		defer os.Unsetenv("SNMP_TEST_CREDENTIALS_PASSPHRASE")
+
+		Convey("Testing community read from environment variable", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["community"] = "env:SNMP_TEST_COMMUNITY"
+			config, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldBeNil)
+			So(config.Community, ShouldEqual, "env-community")
+		})
+
+		Convey("Testing community read from not set environment variable", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["community"] = "env:SNMP_TEST_NOT_SET"
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing community read from file", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["community"] = "file:" + secretFile.Name()
+			config, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldBeNil)
+			So(config.Community, ShouldEqual, "file-community")
+		})
+
+		Convey("Testing credentials read from encrypted credentials file", func() {
+			agentConfig := getCorrectAgentConfig6()
+			agentConfig["auth_password"] = "credentials:auth"
+			agentConfig["credentials_file"] = credentialsFile.Name()
+			agentConfig["credentials_passphrase"] = "env:SNMP_TEST_CREDENTIALS_PASSPHRASE"
+			config, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldBeNil)
+			So(config.AuthPassword, ShouldEqual, "authpassphrase")
+		})
+
+		Convey("Testing credentials file with incorrect pass phrase", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["community"] = "credentials:community"
+			agentConfig["credentials_file"] = credentialsFile.Name()
+			agentConfig["credentials_passphrase"] = "incorrect"
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing missing credential in credentials file", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["community"] = "credentials:missing"
+			agentConfig["credentials_file"] = credentialsFile.Name()
+			agentConfig["credentials_passphrase"] = "testpassphrase"
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing credentials file without pass phrase", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["community"] = "credentials:community"
+			agentConfig["credentials_file"] = credentialsFile.Name()
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestCredentialSets(t *testing.T) {
+	Convey("Testing credential sets", t, func() {
+
+		Convey("Testing correct credential sets", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["credential_sets"] = `[
+				{"name": "old", "community": "public-old"},
+				{"community": "public-new"},
+				{"snmp_version": "v3", "user_name": "user", "security_level": "AuthNoPriv", "auth_protocol": "SHA", "auth_password": "password"}
+			]`
+			config, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldBeNil)
+			So(config.Community, ShouldEqual, "public-old")
+			So(config.CredentialSets, ShouldHaveLength, 3)
+			So(config.CredentialSets[0].CredentialSet, ShouldEqual, "old")
+			So(config.CredentialSets[1].CredentialSet, ShouldEqual, "2")
+			So(config.CredentialSets[1].Community, ShouldEqual, "public-new")
+			So(config.CredentialSets[2].SnmpVersion, ShouldEqual, "v3")
+			So(config.CredentialSets[2].Address, ShouldEqual, "127.0.0.1:161")
+		})
+
+		Convey("Testing credential sets which cannot be unmarshalled", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["credential_sets"] = `{"community": "public-old"}`
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing credential set with unsupported parameter", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["credential_sets"] = `[{"community": "public-old", "snmp_agent_address": "127.0.0.2"}]`
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing incorrect credential set", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["credential_sets"] = `[{"community": "public-old"}, {"snmp_version": "v3", "user_name": "user"}]`
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestAgentAddress(t *testing.T) {
+	Convey("Testing SNMP agent address and network", t, func() {
+
+		Convey("Testing default network and port", func() {
+			config, serr := GetSnmpAgentConfig(getCorrectAgentConfig1())
+			So(serr, ShouldBeNil)
+			So(config.Network, ShouldEqual, "udp")
+			So(config.Address, ShouldEqual, "127.0.0.1:161")
+			So(config.DNSRefreshInterval, ShouldEqual, defaultDNSRefreshInterval)
+		})
+
+		Convey("Testing normalization of correct addresses", func() {
+			addresses := []struct {
+				network    string
+				address    string
+				normalized string
+			}{
+				{"udp", "127.0.0.1:1161", "127.0.0.1:1161"},
+				{"tcp", " 192.168.1.1 ", "192.168.1.1:161"},
+				{"tcp4", "Switch-1.Example.COM.", "switch-1.example.com:161"},
+				{"udp6", "[2001:DB8::0:1]:1161", "[2001:db8::1]:1161"},
+				{"udp6", "[2001:db8::1]", "[2001:db8::1]:161"},
+				{"tcp6", "2001:db8:0:0::1", "[2001:db8::1]:161"},
+				{"udp", "[fe80::1%eth0]:161", "[fe80::1%eth0]:161"},
+				{"udp", "::ffff:10.0.0.1", "10.0.0.1:161"},
+			}
+			for _, a := range addresses {
+				agentConfig := getCorrectAgentConfig1()
+				agentConfig["network"] = a.network
+				agentConfig["snmp_agent_address"] = a.address
+				config, serr := GetSnmpAgentConfig(agentConfig)
+				So(serr, ShouldBeNil)
+				So(config.Address, ShouldEqual, a.normalized)
+			}
+		})
+
+		Convey("Testing incorrect addresses", func() {
+			addresses := []struct {
+				network string
+				address string
+			}{
+				{"sctp", "127.0.0.1"},
+				{"udp", "127.0.0.1:"},
+				{"udp", "127.0.0.1:0"},
+				{"udp", "127.0.0.1:65536"},
+				{"udp", "127.0.0.1:snmp"},
+				{"udp", ":161"},
+				{"udp", "[2001:db8::1]:161:161"},
+				{"udp", "host%eth0"},
+				{"udp4", "[2001:db8::1]"},
+				{"udp6", "127.0.0.1"},
+			}
+			for _, a := range addresses {
+				agentConfig := getCorrectAgentConfig1()
+				agentConfig["network"] = a.network
+				agentConfig["snmp_agent_address"] = a.address
+				_, serr := GetSnmpAgentConfig(agentConfig)
+				So(serr, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestDiscoveryConfig(t *testing.T) {
+	Convey("Testing configuration of discovery", t, func() {
+
+		Convey("Testing configuration without discovery", func() {
+			_, ok, err := GetDiscoveryConfig(getCorrectAgentConfig1())
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Testing ranges and default parameters", func() {
+			agentConfig := getCorrectAgentConfig1()
+			delete(agentConfig, "snmp_agent_address")
+			agentConfig["discovery_ranges"] = "127.0.0.0/30, 127.0.0.9, 127.0.0.2"
+			agentConfig["discovery_profiles"] = `{".1.3.6.1.4.1.9": "if-mib, entity", "1.3.6.1.4.1.9.1.516": "routing"}`
+
+			config, ok, err := GetDiscoveryConfig(agentConfig)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(config.Addresses, ShouldResemble, []string{"127.0.0.1:161", "127.0.0.2:161", "127.0.0.9:161"})
+			So(config.Rate, ShouldEqual, defaultDiscoveryRate)
+			So(config.Interval, ShouldEqual, defaultDiscoveryInterval)
+			So(config.Agent.Community, ShouldEqual, "public")
+			So(config.ProfilesFor("1.3.6.1.4.1.9.1.516"), ShouldResemble, []string{"if-mib", "entity", "routing"})
+			So(config.ProfilesFor(".1.3.6.1.4.1.99"), ShouldBeEmpty)
+
+			names, err := GetDiscoveryProfileNames(agentConfig)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"entity", "if-mib", "routing"})
+		})
+
+		Convey("Testing port and IPv6 ranges", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["discovery_ranges"] = "2001:db8::/127"
+			agentConfig["discovery_port"] = int64(1161)
+
+			config, _, err := GetDiscoveryConfig(agentConfig)
+			So(err, ShouldBeNil)
+			So(config.Addresses, ShouldResemble, []string{"[2001:db8::]:1161", "[2001:db8::1]:1161"})
+		})
+
+		Convey("Testing key of configuration", func() {
+			key := func(c map[string]interface{}) string {
+				agentConfig := getCorrectAgentConfig1()
+				agentConfig["discovery_ranges"] = "127.0.0.0/30"
+				for k, v := range c {
+					agentConfig[k] = v
+				}
+				config, _, err := GetDiscoveryConfig(agentConfig)
+				So(err, ShouldBeNil)
+				return config.Key()
+			}
+
+			So(key(nil), ShouldEqual, key(nil))
+			So(key(nil), ShouldNotContainSubstring, `"public"`)
+			So(key(map[string]interface{}{"community": "private"}), ShouldNotEqual, key(nil))
+			So(key(map[string]interface{}{"retries": int64(7)}), ShouldNotEqual, key(nil))
+			So(key(map[string]interface{}{"credential_sets": `[{"name": "old", "community": "public-old"}]`}), ShouldNotEqual,
+				key(map[string]interface{}{"credential_sets": `[{"name": "old", "community": "public-new"}]`}))
+		})
+
+		Convey("Testing incorrect configuration", func() {
+			configs := []map[string]interface{}{
+				{"discovery_ranges": "127.0.0.0/8"},
+				{"discovery_ranges": "127.0.0.300"},
+				{"discovery_ranges": "127.0.0.0/33"},
+				{"discovery_ranges": " , "},
+				{"discovery_ranges": "127.0.0.1", "discovery_rate": int64(100000)},
+				{"discovery_ranges": "127.0.0.1", "discovery_profiles": `["if-mib"]`},
+				{"discovery_ranges": "127.0.0.1", "snmp_version": "v4"},
+			}
+			for _, c := range configs {
+				agentConfig := getCorrectAgentConfig1()
+				for k, v := range c {
+					agentConfig[k] = v
+				}
+				_, ok, err := GetDiscoveryConfig(agentConfig)
+				So(ok, ShouldBeTrue)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestRedactedAgentConfig(t *testing.T) {
+	Convey("Testing redaction of SNMP agent configuration", t, func() {
+		config := SnmpAgent{Address: "127.0.0.1", Community: "public", AuthPassword: "authpassphrase",
+			PrivPassword: "env:SNMP_PRIV_PASSWORD", CredentialsPassphrase: "passphrase"}
+
+		Convey("Testing formatted configuration", func() {
+			formatted := fmt.Sprintf("%v", config)
+			So(formatted, ShouldContainSubstring, "127.0.0.1")
+			So(formatted, ShouldContainSubstring, "env:SNMP_PRIV_PASSWORD")
+			So(formatted, ShouldNotContainSubstring, "public")
+			So(formatted, ShouldNotContainSubstring, "authpassphrase")
+			So(formatted, ShouldNotContainSubstring, "Passphrase:passphrase")
+		})
+
+		Convey("Testing configuration encoded to JSON", func() {
+			encoded, err := json.Marshal(config)
+			So(err, ShouldBeNil)
+			So(string(encoded), ShouldContainSubstring, "127.0.0.1")
+			So(string(encoded), ShouldNotContainSubstring, "public")
+			So(string(encoded), ShouldNotContainSubstring, "authpassphrase")
+		})
+	})
+}
+
+func TestCaptureConfig(t *testing.T) {
+	Convey("Testing configuration of capture of exchanges with SNMP agent", t, func() {
+
+		Convey("Testing correct capture mode", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["capture_mode"] = "replay"
+			agentConfig["capture_file"] = "/tmp/capture.json"
+			config, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldBeNil)
+			So(config.CaptureMode, ShouldEqual, CaptureModeReplay)
+			So(config.CaptureFile, ShouldEqual, "/tmp/capture.json")
+		})
+
+		Convey("Testing incorrect capture mode", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["capture_mode"] = "play"
+			agentConfig["capture_file"] = "/tmp/capture.json"
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+		})
+
+		Convey("Testing missing capture file", func() {
+			agentConfig := getCorrectAgentConfig1()
+			agentConfig["capture_mode"] = "record"
+			_, serr := GetSnmpAgentConfig(agentConfig)
+			So(serr, ShouldNotBeNil)
+			So(serr.Error(), ShouldContainSubstring, "capture_file")
+		})
+	})
+}
+
+func TestConfigParameters(t *testing.T) {
+	Convey("Testing declared configuration parameters", t, func() {
+		parameters := map[string]ConfigParameter{}
+		for _, parameter := range append(append([]ConfigParameter{}, SnmpAgentConfigParameters...), DiscoveryConfigParameters...) {
+			So(parameters, ShouldNotContainKey, parameter.Name)
+			So(parameter.Type, ShouldBeIn, []string{ParameterString, ParameterInt})
+			parameters[parameter.Name] = parameter
+		}
+
+		Convey("Testing that all parameters of SNMP agent are declared", func() {
+			agentType := reflect.TypeOf(SnmpAgent{})
+			for i := 0; i < agentType.NumField(); i++ {
+				name := agentType.Field(i).Tag.Get("mapstructure")
+				if name == "-" || name == agentCredentialSet {
+					continue
+				}
+				So(parameters, ShouldContainKey, name)
+			}
+		})
+
+		Convey("Testing that all parameters of discovery are declared", func() {
+			discoveryType := reflect.TypeOf(Discovery{})
+			for i := 0; i < discoveryType.NumField(); i++ {
+				if name := discoveryType.Field(i).Tag.Get("mapstructure"); name != "-" {
+					So(parameters, ShouldContainKey, name)
+				}
+			}
+			So(parameters, ShouldContainKey, discoveryProfiles)
+		})
+
+		Convey("Testing that configuration with default values is decoded", func() {
+			configMap := plugin.NewConfig()
+			for name, parameter := range parameters {
+				if parameter.Default != nil {
+					configMap[name] = parameter.Default
+				}
+			}
+			configMap[agentAddress] = "127.0.0.1"
+			configMap[agentSnmpVersion] = "v2c"
+			configMap[agentCommunity] = "public"
+
+			config, err := GetSnmpAgentConfig(configMap)
+			So(err, ShouldBeNil)
+			So(config.Network, ShouldEqual, defaultNetwork)
+			So(config.Retries, ShouldEqual, defaultRetries)
+			So(config.Timeout, ShouldEqual, defaultTimeout)
+			So(config.DNSRefreshInterval, ShouldEqual, defaultDNSRefreshInterval)
+
+			configMap[discoveryRanges] = "127.0.0.1"
+			discovery, ok, err := GetDiscoveryConfig(configMap)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(discovery.Port, ShouldEqual, defaultDiscoveryPort)
+			So(discovery.Rate, ShouldEqual, defaultDiscoveryRate)
+			So(discovery.Interval, ShouldEqual, defaultDiscoveryInterval)
+		})
+
+		Convey("Testing that defaults of parameters are valid", func() {
+			for _, parameter := range parameters {
+				if parameter.Default == nil {
+					continue
+				}
+				if parameter.Type == ParameterInt {
+					value, ok := parameter.Default.(int64)
+					So(ok, ShouldBeTrue)
+					if parameter.Min != nil {
+						So(value, ShouldBeGreaterThanOrEqualTo, *parameter.Min)
+					}
+					if parameter.Max != nil {
+						So(value, ShouldBeLessThanOrEqualTo, *parameter.Max)
+					}
+					continue
+				}
+				So(parameter.Default, ShouldHaveSameTypeAs, "")
+				if len(parameter.Options) > 0 {
+					So(parameter.Default, ShouldBeIn, parameter.Options)
+				}
+			}
+		})
+
+		Convey("Testing validation of required parameters and possible options", func() {
+			So(ValidateConfigParameters(plugin.Config{agentAddress: "127.0.0.1", agentSnmpVersion: "v2c"}), ShouldBeNil)
+			So(ValidateConfigParameters(plugin.Config{discoveryRanges: "127.0.0.0/30"}), ShouldBeNil)
+			So(ValidateConfigParameters(plugin.Config{agentSnmpVersion: "v2c"}), ShouldNotBeNil)
+			So(ValidateConfigParameters(plugin.Config{agentAddress: ""}), ShouldNotBeNil)
+
+			for name, value := range map[string]string{agentSnmpVersion: "v4", agentNetwork: "sctp", agentSecurityLevel: "authPriv",
+				agentAuthProtocol: "SHA512", agentPrivProtocol: "AES256", agentCaptureMode: "play"} {
+				err := ValidateConfigParameters(plugin.Config{agentAddress: "127.0.0.1", name: value})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, name)
+			}
+		})
+	})
+}
+
+//encryptedCredentials contains {"community":"secret-community","auth":"authpassphrase"} encrypted with
+//`openssl enc -aes-256-cbc -pbkdf2 -salt -a -pass pass:testpassphrase`
+const encryptedCredentials = `U2FsdGVkX18duL87jmm8Zz9IrNzRCSH8cSm0z3CAIksfGxftZ/CJbYPbOTx2I9o0
+viCv9gtuCLA3/qP4aNaT7pMSIeue+YhZ5glIuTCUETM=
+`
@@ -88,6 +88,38 @@ func getCorrectConfig4() Metrics {
 	return metricConfig
 }
 
+func getCorrectConfig5() Metrics {
+	//table with composite index (ipNetToMediaPhysAddress)
+	metricConfig := []Metric{Metric{
+		Oid:  ".1.3.6.1.2.1.4.22.1.2",
+		Mode: "table",
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 10, Encoding: "integer", Name: "ifIndex", Description: "description"},
+			Namespace{Source: "index", OidPart: 11, Encoding: "ipv4", Name: "address", Description: "description"},
+			Namespace{Source: "string", String: "physAddress"},
+		},
+	}}
+	return metricConfig
+}
+
+func getCorrectConfig6() Metrics {
+	//namespace element and tags joined with other tables
+	metricConfig := []Metric{Metric{
+		Oid:  ".1.3.6.1.2.1.4.20.1.3",
+		Mode: "table",
+		Namespace: []Namespace{
+			Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.2.2.1.2", Join: "pointer", IndexOid: ".1.3.6.1.2.1.4.20.1.2", Name: "interface", Description: "description",
+				CacheTTL: 300, CacheInvalidateOid: ".1.3.6.1.2.1.31.1.5.0"},
+			Namespace{Source: "string", String: "netmask"},
+		},
+		Tags: []Tag{
+			Tag{Name: "alias", Oid: ".1.3.6.1.2.1.31.1.1.1.18", Join: "pointer", IndexOid: ".1.3.6.1.2.1.4.20.1.2"},
+			Tag{Name: "sysName", Oid: ".1.3.6.1.2.1.1.5.0"},
+		},
+	}}
+	return metricConfig
+}
+
 func getWrongConfig1() Metrics {
 	metricConfig := []Metric{Metric{}}
 	return metricConfig
@@ -147,6 +179,139 @@ func getWrongConfig6() Metrics {
 	return metricConfig
 }
 
+func getWrongConfig7() Metrics {
+	metricConfig := []Metric{Metric{
+		Oid:  ".1.3.6.1.2.1.4.22.1.2",
+		Mode: "table",
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 11, Encoding: "ipv5", Name: "address", Description: "description"},
+			Namespace{Source: "string", String: "physAddress"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig8() Metrics {
+	metricConfig := []Metric{Metric{
+		Oid:  ".1.3.6.1.2.1.4.20.1.3",
+		Mode: "table",
+		Namespace: []Namespace{
+			Namespace{Source: "snmp", Oid: ".1.3.6.1.2.1.2.2.1.2", Join: "pointer", Name: "interface", Description: "description"},
+			Namespace{Source: "string", String: "netmask"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig9() Metrics {
+	metricConfig := []Metric{Metric{
+		Oid:       ".1.3.6.1.2.1.4.20.1.3",
+		Mode:      "table",
+		Namespace: []Namespace{Namespace{Source: "string", String: "netmask"}},
+		Tags:      []Tag{Tag{Name: "alias", Join: "index"}},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig10() Metrics {
+	metricConfig := []Metric{Metric{
+		Oid:  ".1.3.6.1.2.1.2.2.1.10",
+		Mode: "table",
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 10, CacheTTL: 300, Name: "ifIndex", Description: "description"},
+			Namespace{Source: "string", String: "in_octets"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig11() Metrics {
+	//divide_by_OID cannot be used in single mode
+	metricConfig := []Metric{Metric{
+		Oid:         ".1.3.6.1.2.1.31.1.1.1.6.1",
+		Mode:        "single",
+		DivideByOid: ".1.3.6.1.2.1.31.1.1.1.15",
+		Namespace: []Namespace{
+			Namespace{Source: "string", String: "in_utilization"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig12() Metrics {
+	//multiply_by_OID cannot be used in single mode
+	metricConfig := []Metric{Metric{
+		Oid:           ".1.3.6.1.2.1.25.2.3.1.5.1",
+		Mode:          "single",
+		MultiplyByOid: ".1.3.6.1.2.1.25.2.3.1.4",
+		Namespace: []Namespace{
+			Namespace{Source: "string", String: "size_bytes"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig13() Metrics {
+	//incorrect encoding in subtype_encoding
+	metricConfig := []Metric{Metric{
+		Oid:             ".1.0.8802.1.1.2.1.4.1.1.5",
+		Mode:            "table",
+		SubtypeOid:      ".1.0.8802.1.1.2.1.4.1.1.4",
+		SubtypeEncoding: map[string]string{"4": "mac", "5": "unknown"},
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 12, Name: "port", Description: "description"},
+			Namespace{Source: "string", String: "chassis_id"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig14() Metrics {
+	//subtype_OID of tag requires join
+	metricConfig := []Metric{Metric{
+		Oid:  ".1.0.8802.1.1.2.1.4.1.1.9",
+		Mode: "table",
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 12, Name: "port", Description: "description"},
+			Namespace{Source: "string", String: "system_name"},
+		},
+		Tags: []Tag{
+			Tag{Name: "chassis_id", Oid: ".1.0.8802.1.1.2.1.4.1.1.5", SubtypeOid: ".1.0.8802.1.1.2.1.4.1.1.4"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig15() Metrics {
+	//change cannot be used together with rate
+	metricConfig := []Metric{Metric{
+		Oid:    ".1.3.6.1.2.1.15.3.1.2",
+		Mode:   "table",
+		Change: true,
+		Rate:   true,
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 10, Encoding: "ipv4", Name: "peer", Description: "description"},
+			Namespace{Source: "string", String: "state_change"},
+		},
+	}}
+	return metricConfig
+}
+
+func getWrongConfig16() Metrics {
+	//divide_by_fallback_OID requires divide_by_OID
+	metricConfig := []Metric{Metric{
+		Oid:                 ".1.3.6.1.2.1.2.2.1.10",
+		Mode:                "table",
+		Rate:                true,
+		DivideByFallbackOid: ".1.3.6.1.2.1.2.2.1.5",
+		Namespace: []Namespace{
+			Namespace{Source: "index", OidPart: 10, Name: "ifIndex", Description: "description"},
+			Namespace{Source: "string", String: "in_utilization"},
+		},
+	}}
+	return metricConfig
+}
+
 func getCorrectAgentConfig1() map[string]interface{} {
 	//configuration for SNMP v1 and SNMP v2c
 	agentConfig := make(map[string]interface{})
@@ -193,6 +358,30 @@ func getCorrectAgentConfig3() map[string]interface{} {
 	return agentConfig
 }
 
+func getCorrectAgentConfig5() map[string]interface{} {
+	//configuration for SNMP v3 without authentication and privacy, protocols are not required
+	agentConfig := make(map[string]interface{})
+	agentConfig["snmp_agent_name"] = "agent1"
+	agentConfig["snmp_agent_address"] = "127.0.0.1"
+	agentConfig["snmp_version"] = "v3"
+	agentConfig["user_name"] = "user"
+	agentConfig["security_level"] = "NoAuthNoPriv"
+	return agentConfig
+}
+
+func getCorrectAgentConfig6() map[string]interface{} {
+	//configuration for SNMP v3 with authentication and without privacy, privacy protocol is not required
+	agentConfig := make(map[string]interface{})
+	agentConfig["snmp_agent_name"] = "agent1"
+	agentConfig["snmp_agent_address"] = "127.0.0.1"
+	agentConfig["snmp_version"] = "v3"
+	agentConfig["user_name"] = "user"
+	agentConfig["security_level"] = "AuthNoPriv"
+	agentConfig["auth_password"] = "password"
+	agentConfig["auth_protocol"] = "SHA"
+	return agentConfig
+}
+
 func getWrongAgentConfig1() map[string]interface{} {
 	//missing required parameter snmp_version
 	agentConfig := make(map[string]interface{})
@@ -316,7 +505,7 @@ func getWrongAgentConfig11() map[string]interface{} {
 	agentConfig["snmp_agent_address"] = "127.0.0.1"
 	agentConfig["snmp_version"] = "v3"
 	agentConfig["user_name"] = "user"
-	agentConfig["security_level"] = "NoAuthNoPriv"
+	agentConfig["security_level"] = "AuthNoPriv"
 	agentConfig["auth_password"] = "password"
 	agentConfig["priv_password"] = "password"
 	agentConfig["priv_protocol"] = "DES"
@@ -324,16 +513,14 @@ func getWrongAgentConfig11() map[string]interface{} {
 }
 
 func getWrongAgentConfig12() map[string]interface{} {
-	//missing required parameter - auth_protocol
+	//missing required parameter - auth_password
 	agentConfig := make(map[string]interface{})
 	agentConfig["snmp_agent_name"] = "agent1"
 	agentConfig["snmp_agent_address"] = "127.0.0.1"
 	agentConfig["snmp_version"] = "v3"
 	agentConfig["user_name"] = "user"
-	agentConfig["security_level"] = "NoAuthNoPriv"
-	agentConfig["auth_password"] = "password"
-	agentConfig["priv_password"] = "password"
-	agentConfig["priv_protocol"] = "DES"
+	agentConfig["security_level"] = "AuthNoPriv"
+	agentConfig["auth_protocol"] = "SHA"
 	return agentConfig
 }
 
@@ -344,7 +531,7 @@ func getWrongAgentConfig13() map[string]interface{} {
 	agentConfig["snmp_agent_address"] = "127.0.0.1"
 	agentConfig["snmp_version"] = "v3"
 	agentConfig["user_name"] = "user"
-	agentConfig["security_level"] = "NoAuthNoPriv"
+	agentConfig["security_level"] = "AuthPriv"
 	agentConfig["auth_password"] = "password"
 	agentConfig["auth_protocol"] = "MD5"
 	agentConfig["priv_password"] = "password"
@@ -369,3 +556,55 @@ func getWrongAgentConfig16() map[string]interface{} {
 	//empty agent configuration
 	return nil
 }
+
+func getWrongAgentConfig17() map[string]interface{} {
+	//missing required parameter - user_name
+	agentConfig := make(map[string]interface{})
+	agentConfig["snmp_agent_name"] = "agent1"
+	agentConfig["snmp_agent_address"] = "127.0.0.1"
+	agentConfig["snmp_version"] = "v3"
+	agentConfig["security_level"] = "NoAuthNoPriv"
+	return agentConfig
+}
+
+func getWrongAgentConfig18() map[string]interface{} {
+	//missing required parameter - priv_password
+	agentConfig := make(map[string]interface{})
+	agentConfig["snmp_agent_name"] = "agent1"
+	agentConfig["snmp_agent_address"] = "127.0.0.1"
+	agentConfig["snmp_version"] = "v3"
+	agentConfig["user_name"] = "user"
+	agentConfig["security_level"] = "AuthPriv"
+	agentConfig["auth_password"] = "password"
+	agentConfig["auth_protocol"] = "SHA"
+	agentConfig["priv_protocol"] = "AES"
+	return agentConfig
+}
+
+func getWrongAgentConfig19() map[string]interface{} {
+	//too short pass phrase - auth_password
+	agentConfig := make(map[string]interface{})
+	agentConfig["snmp_agent_name"] = "agent1"
+	agentConfig["snmp_agent_address"] = "127.0.0.1"
+	agentConfig["snmp_version"] = "v3"
+	agentConfig["user_name"] = "user"
+	agentConfig["security_level"] = "AuthNoPriv"
+	agentConfig["auth_password"] = "pass"
+	agentConfig["auth_protocol"] = "SHA"
+	return agentConfig
+}
+
+func getWrongAgentConfig20() map[string]interface{} {
+	//too short pass phrase - priv_password
+	agentConfig := make(map[string]interface{})
+	agentConfig["snmp_agent_name"] = "agent1"
+	agentConfig["snmp_agent_address"] = "127.0.0.1"
+	agentConfig["snmp_version"] = "v3"
+	agentConfig["user_name"] = "user"
+	agentConfig["security_level"] = "AuthPriv"
+	agentConfig["auth_password"] = "password"
+	agentConfig["auth_protocol"] = "SHA"
+	agentConfig["priv_password"] = "pass"
+	agentConfig["priv_protocol"] = "AES"
+	return agentConfig
+}
@@ -0,0 +1,297 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configReader
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	//credentialSourceEnv prefix of credential which is read from environment variable
+	credentialSourceEnv = "env:"
+
+	//credentialSourceFile prefix of credential which is read from file (e.g. Kubernetes secret)
+	credentialSourceFile = "file:"
+
+	//credentialSourceCredentialsFile prefix of credential which is read from encrypted credentials file
+	credentialSourceCredentialsFile = "credentials:"
+
+	//redactedValue replaces secrets in logs
+	redactedValue = "<redacted>"
+
+	//opensslSaltHeader header of file encrypted with `openssl enc` with salt
+	opensslSaltHeader = "Salted__"
+
+	//opensslSaltLength length of salt used by `openssl enc`
+	opensslSaltLength = 8
+
+	//opensslPbkdf2Iterations default number of PBKDF2 iterations used by `openssl enc -pbkdf2`
+	opensslPbkdf2Iterations = 10000
+
+	//credentialSetName indicates name of credential set in configuration of credential set
+	credentialSetName = "name"
+)
+
+var (
+	//credentialSetParameters slice of SNMP agent configuration parameters which can be set in credential set
+	credentialSetParameters = []interface{}{credentialSetName, agentSnmpVersion, agentCommunity, agentUserName,
+		agentSecurityLevel, agentAuthPassword, agentAuthProtocol, agentPrivPassword, agentPrivProtocol,
+		agentSecurityEngineId, agentContextEngineID, agentContextName}
+)
+
+//snmpAgent has the same fields as SnmpAgent but without methods, it is used to format redacted configuration
+type snmpAgent SnmpAgent
+
+//String returns SNMP agent configuration with redacted secrets, it is used when configuration is logged
+func (a SnmpAgent) String() string {
+	return fmt.Sprintf("%+v", snmpAgent(a.redacted()))
+}
+
+//MarshalJSON encodes SNMP agent configuration with redacted secrets, it is used by JSON log formatters
+func (a SnmpAgent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(snmpAgent(a.redacted()))
+}
+
+//redacted returns copy of SNMP agent configuration with secrets replaced
+func (a SnmpAgent) redacted() SnmpAgent {
+	a.Community = redactSecret(a.Community)
+	a.AuthPassword = redactSecret(a.AuthPassword)
+	a.PrivPassword = redactSecret(a.PrivPassword)
+	a.CredentialsPassphrase = redactSecret(a.CredentialsPassphrase)
+	return a
+}
+
+//redactSecret replaces secret value, references to external sources are not secret and they are kept to ease debugging
+func redactSecret(s string) string {
+	if s == "" || isCredentialReference(s) {
+		return s
+	}
+	return redactedValue
+}
+
+//secretsHash returns SHA-256 hash of secrets of SNMP agent configuration and its credential sets,
+//it tells apart configurations which differ only in secrets without revealing them
+func (a SnmpAgent) secretsHash() string {
+	hash := sha256.New()
+	for _, agent := range append([]SnmpAgent{a}, a.CredentialSets...) {
+		for _, secret := range []string{agent.Community, agent.AuthPassword, agent.PrivPassword, agent.CredentialsPassphrase} {
+			fmt.Fprintf(hash, "%d:%s", len(secret), secret)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+//isCredentialReference checks if value refers to external source of credential
+func isCredentialReference(s string) bool {
+	for _, prefix := range []string{credentialSourceEnv, credentialSourceFile, credentialSourceCredentialsFile} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//getCredentialSets decodes credential sets (JSON array of objects) and creates SNMP agent configuration for each of them,
+//parameters of credential set override parameters of SNMP agent configuration
+func getCredentialSets(configMap plugin.Config) ([]plugin.Config, error) {
+	if _, ok := configMap[agentCredentialSets]; !ok {
+		return nil, nil
+	}
+
+	credentialSetsJSON, err := configMap.GetString(agentCredentialSets)
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect value of parameter (%s): %v", agentCredentialSets, err)
+	}
+
+	credentialSets := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(credentialSetsJSON), &credentialSets); err != nil {
+		return nil, fmt.Errorf("Incorrect value of parameter (%s), JSON array of credential sets cannot be unmarshalled: %v", agentCredentialSets, err)
+	}
+
+	configs := []plugin.Config{}
+	for i, credentialSet := range credentialSets {
+		config := plugin.NewConfig()
+		for k, v := range configMap {
+			config[k] = v
+		}
+		config[agentCredentialSet] = strconv.Itoa(i + 1)
+
+		for k, v := range credentialSet {
+			if !checkPossibleOptions(k, credentialSetParameters) {
+				return nil, fmt.Errorf("Incorrect parameter (%s) in credential set %d, possible options: %v", k, i+1, credentialSetParameters)
+			}
+			if k == credentialSetName {
+				config[agentCredentialSet] = v
+				continue
+			}
+			config[k] = v
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+//resolveCredentials replaces references to external sources of credentials with values read from these sources
+func resolveCredentials(config *SnmpAgent) error {
+	var credentials map[string]string
+
+	secrets := []struct {
+		parameter string
+		value     *string
+	}{
+		{agentCommunity, &config.Community},
+		{agentAuthPassword, &config.AuthPassword},
+		{agentPrivPassword, &config.PrivPassword},
+	}
+
+	for _, secret := range secrets {
+		if !strings.HasPrefix(*secret.value, credentialSourceCredentialsFile) {
+			value, err := readCredential(*secret.value)
+			if err != nil {
+				return fmt.Errorf("Cannot read value of parameter (%s): %v", secret.parameter, err)
+			}
+			*secret.value = value
+			continue
+		}
+
+		if credentials == nil {
+			var err error
+			credentials, err = readCredentialsFile(config.CredentialsFile, config.CredentialsPassphrase)
+			if err != nil {
+				return fmt.Errorf("Cannot read value of parameter (%s): %v", secret.parameter, err)
+			}
+		}
+
+		name := strings.TrimPrefix(*secret.value, credentialSourceCredentialsFile)
+		value, ok := credentials[name]
+		if !ok {
+			return fmt.Errorf("Cannot read value of parameter (%s): credential `%s` not found in credentials file (%s)",
+				secret.parameter, name, config.CredentialsFile)
+		}
+		*secret.value = value
+	}
+	return nil
+}
+
+//readCredential reads credential from environment variable or file, other values are returned unchanged
+func readCredential(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, credentialSourceEnv):
+		name := strings.TrimPrefix(s, credentialSourceEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable `%s` is not set", name)
+		}
+		return value, nil
+	case strings.HasPrefix(s, credentialSourceFile):
+		content, err := cfgReader.ReadFile(strings.TrimPrefix(s, credentialSourceFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return s, nil
+}
+
+//readCredentialsFile decrypts credentials file and decodes credentials (JSON object with names and values of credentials),
+//file is expected in the format of `openssl enc -aes-256-cbc -pbkdf2` (binary or base64 encoded)
+func readCredentialsFile(path string, passphrase string) (map[string]string, error) {
+	if !checkSetParameter(path) {
+		return nil, fmt.Errorf(missingRequiredParameter, agentCredentialsFile)
+	}
+
+	passphrase, err := readCredential(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read value of parameter (%s): %v", agentCredentialsPassphrase, err)
+	}
+	if !checkSetParameter(passphrase) {
+		return nil, fmt.Errorf(missingRequiredParameter, agentCredentialsPassphrase)
+	}
+
+	content, err := cfgReader.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptOpensslAes256Cbc(content, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("credentials file (%s) cannot be decrypted: %v", path, err)
+	}
+
+	credentials := map[string]string{}
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, fmt.Errorf("credentials file (%s) cannot be unmarshalled: %v", path, err)
+	}
+	return credentials, nil
+}
+
+//decryptOpensslAes256Cbc decrypts data encrypted with `openssl enc -aes-256-cbc -pbkdf2 -md sha256`
+func decryptOpensslAes256Cbc(data []byte, passphrase []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte(opensslSaltHeader)) {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.Replace(data, []byte("\n"), nil, -1)))
+		if err != nil || !bytes.HasPrefix(decoded, []byte(opensslSaltHeader)) {
+			return nil, fmt.Errorf("missing `%s` header", opensslSaltHeader)
+		}
+		data = decoded
+	}
+
+	data = data[len(opensslSaltHeader):]
+	if len(data) < opensslSaltLength+aes.BlockSize || (len(data)-opensslSaltLength)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("incorrect length of encrypted data")
+	}
+	salt, ciphertext := data[:opensslSaltLength], data[opensslSaltLength:]
+
+	keyIv := pbkdf2.Key(passphrase, salt, opensslPbkdf2Iterations, 32+aes.BlockSize, sha256.New)
+	block, err := aes.NewCipher(keyIv[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, keyIv[32:]).CryptBlocks(plaintext, ciphertext)
+
+	//remove PKCS#7 padding, incorrect padding means incorrect pass phrase
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("incorrect pass phrase")
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("incorrect pass phrase")
+		}
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
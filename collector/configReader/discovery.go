@@ -0,0 +1,328 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configReader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	//discoveryRanges indicates comma separated list of CIDR ranges and IP addresses which are swept for SNMP agents
+	discoveryRanges = "discovery_ranges"
+
+	//discoveryPort indicates port of SNMP agents which are discovered
+	discoveryPort = "discovery_port"
+
+	//discoveryRate indicates maximal number of probed addresses per second
+	discoveryRate = "discovery_rate"
+
+	//discoveryInterval indicates interval (in seconds) between sweeps of ranges
+	discoveryInterval = "discovery_interval"
+
+	//discoveryProfiles indicates JSON object which maps prefixes of sysObjectID to comma separated lists of built-in profiles
+	discoveryProfiles = "discovery_profiles"
+
+	//defaultDiscoveryPort default port of SNMP agents which are discovered
+	defaultDiscoveryPort = 161
+
+	//defaultDiscoveryRate default number of probed addresses per second
+	defaultDiscoveryRate = 20
+
+	//maxDiscoveryRate maximal number of probed addresses per second
+	maxDiscoveryRate = 1000
+
+	//defaultDiscoveryInterval default interval (in seconds) between sweeps of ranges
+	defaultDiscoveryInterval = 300
+
+	//maxDiscoveryAddresses maximal number of addresses in all ranges
+	maxDiscoveryAddresses = 65536
+)
+
+//DiscoveryConfigParameters slice of discovery configuration parameters
+var DiscoveryConfigParameters = []ConfigParameter{
+	{Name: discoveryRanges, Type: ParameterString},
+	{Name: discoveryPort, Type: ParameterInt, Default: int64(defaultDiscoveryPort), Min: limit(1), Max: limit(65535)},
+	{Name: discoveryRate, Type: ParameterInt, Default: int64(defaultDiscoveryRate), Min: limit(1), Max: limit(maxDiscoveryRate)},
+	{Name: discoveryInterval, Type: ParameterInt, Default: int64(defaultDiscoveryInterval), Min: limit(1)},
+	{Name: discoveryProfiles, Type: ParameterString},
+}
+
+//Discovery configuration of discovery of SNMP agents in ranges of addresses
+type Discovery struct {
+	Ranges   string `mapstructure:"discovery_ranges"`
+	Port     uint   `mapstructure:"discovery_port"`
+	Rate     uint   `mapstructure:"discovery_rate"`
+	Interval uint   `mapstructure:"discovery_interval"`
+
+	//Addresses addresses of SNMP agents (`host:port`) which are probed in sweep
+	Addresses []string `mapstructure:"-"`
+
+	//Profiles built-in profiles which are used for SNMP agents, key is prefix of sysObjectID
+	Profiles map[string][]string `mapstructure:"-"`
+
+	//Agent configuration of SNMP agent which is used for discovered SNMP agents, credential sets are candidate credentials
+	Agent SnmpAgent `mapstructure:"-"`
+}
+
+//GetDiscoveryConfig decodes and validates configuration of discovery, it indicates if discovery is configured
+func GetDiscoveryConfig(configMap plugin.Config) (Discovery, bool, error) {
+	if _, ok := configMap[discoveryRanges]; !ok {
+		return Discovery{}, false, nil
+	}
+
+	var config Discovery
+	if err := mapstructure.Decode(configMap, &config); err != nil {
+		return config, true, fmt.Errorf("Incorrect configuration of discovery: %v", err)
+	}
+
+	if config.Port == 0 {
+		config.Port = defaultDiscoveryPort
+	}
+	if config.Port > 65535 {
+		return config, true, fmt.Errorf("Incorrect value of parameter (%s), port must be lower than 65536", discoveryPort)
+	}
+
+	if config.Rate == 0 {
+		config.Rate = defaultDiscoveryRate
+	}
+	if config.Rate > maxDiscoveryRate {
+		return config, true, fmt.Errorf("Incorrect value of parameter (%s), rate must not be greater than %d", discoveryRate, maxDiscoveryRate)
+	}
+
+	if config.Interval == 0 {
+		config.Interval = defaultDiscoveryInterval
+	}
+
+	addresses, err := expandRanges(config.Ranges, strconv.FormatUint(uint64(config.Port), 10))
+	if err != nil {
+		return config, true, err
+	}
+	config.Addresses = addresses
+
+	config.Profiles, err = getDiscoveryProfiles(configMap)
+	if err != nil {
+		return config, true, err
+	}
+
+	//configuration of SNMP agent is validated with the first address, addresses of discovered SNMP agents are set in sweep
+	agentConfigMap := plugin.NewConfig()
+	for k, v := range configMap {
+		agentConfigMap[k] = v
+	}
+	agentConfigMap[agentAddress] = addresses[0]
+
+	config.Agent, err = GetSnmpAgentConfig(agentConfigMap)
+	if err != nil {
+		return config, true, fmt.Errorf("Incorrect configuration of SNMP agent for discovery: %v", err)
+	}
+	return config, true, nil
+}
+
+//GetDiscoveryProfileNames returns sorted names of built-in profiles which are used for discovered SNMP agents
+func GetDiscoveryProfileNames(configMap plugin.Config) ([]string, error) {
+	profiles, err := getDiscoveryProfiles(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := map[string]bool{}
+	for _, names := range profiles {
+		for _, name := range names {
+			unique[name] = true
+		}
+	}
+
+	names := []string{}
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+//ProfilesFor returns built-in profiles for SNMP agent, profiles of all prefixes of sysObjectID are returned
+func (d Discovery) ProfilesFor(sysObjectID string) []string {
+	oid := strings.Trim(sysObjectID, ".")
+
+	prefixes := []string{}
+	for prefix := range d.Profiles {
+		if oid == prefix || strings.HasPrefix(oid, prefix+".") {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	profiles := []string{}
+	for _, prefix := range prefixes {
+		profiles = append(profiles, d.Profiles[prefix]...)
+	}
+	return profiles
+}
+
+//Key identifies configuration of discovery including configuration of SNMP agent and credential sets,
+//secrets are redacted in JSON of configuration and only their hash is added to key
+func (d Discovery) Key() string {
+	//addresses are expanded from ranges and port which are already in key
+	d.Addresses = nil
+	config, err := json.Marshal(d)
+	if err != nil {
+		config = []byte(fmt.Sprintf("%+v", d))
+	}
+	return string(config) + ":" + d.Agent.secretsHash()
+}
+
+//getDiscoveryProfiles decodes built-in profiles which are used for discovered SNMP agents (JSON object)
+func getDiscoveryProfiles(configMap plugin.Config) (map[string][]string, error) {
+	profiles := map[string][]string{}
+	if _, ok := configMap[discoveryProfiles]; !ok {
+		return profiles, nil
+	}
+
+	profilesJSON, err := configMap.GetString(discoveryProfiles)
+	if err != nil {
+		return nil, fmt.Errorf("Incorrect value of parameter (%s): %v", discoveryProfiles, err)
+	}
+
+	prefixes := map[string]string{}
+	if err := json.Unmarshal([]byte(profilesJSON), &prefixes); err != nil {
+		return nil, fmt.Errorf("Incorrect value of parameter (%s), JSON object which maps prefixes of sysObjectID to profiles cannot be unmarshalled: %v", discoveryProfiles, err)
+	}
+
+	for prefix, names := range prefixes {
+		key := strings.Trim(prefix, ".")
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				profiles[key] = append(profiles[key], name)
+			}
+		}
+	}
+	return profiles, nil
+}
+
+//expandRanges returns addresses (`host:port`) of all hosts in comma separated list of CIDR ranges and IP addresses,
+//network and broadcast addresses of IPv4 ranges are skipped
+func expandRanges(ranges string, port string) ([]string, error) {
+	addresses := []string{}
+	unique := map[string]bool{}
+
+	add := func(ip net.IP) error {
+		address := net.JoinHostPort(ip.String(), port)
+		if unique[address] {
+			return nil
+		}
+		if len(addresses) >= maxDiscoveryAddresses {
+			return fmt.Errorf("Incorrect value of parameter (%s), ranges contain more than %d addresses", discoveryRanges, maxDiscoveryAddresses)
+		}
+		unique[address] = true
+		addresses = append(addresses, address)
+		return nil
+	}
+
+	for _, r := range strings.Split(ranges, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		if !strings.Contains(r, "/") {
+			ip := net.ParseIP(r)
+			if ip == nil {
+				return nil, fmt.Errorf("Incorrect value of parameter (%s), incorrect IP address (%s)", discoveryRanges, r)
+			}
+			if err := add(ip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, fmt.Errorf("Incorrect value of parameter (%s): %v", discoveryRanges, err)
+		}
+
+		ones, bits := network.Mask.Size()
+		if bits-ones > 16 {
+			return nil, fmt.Errorf("Incorrect value of parameter (%s), range (%s) contains more than %d addresses", discoveryRanges, r, maxDiscoveryAddresses)
+		}
+
+		first, last := network.IP, lastAddress(network)
+		if bits == 8*net.IPv4len && bits-ones > 1 {
+			first, last = nextAddress(first), previousAddress(last)
+		}
+		for ip := first; ; ip = nextAddress(ip) {
+			if err := add(ip); err != nil {
+				return nil, err
+			}
+			if ip.Equal(last) {
+				break
+			}
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf(missingRequiredParameter, discoveryRanges)
+	}
+	return addresses, nil
+}
+
+//lastAddress returns the last address in network
+func lastAddress(network *net.IPNet) net.IP {
+	ip := make(net.IP, len(network.IP))
+	for i := range network.IP {
+		ip[i] = network.IP[i] | ^network.Mask[i]
+	}
+	return ip
+}
+
+//nextAddress returns address which follows given address
+func nextAddress(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+//previousAddress returns address which precedes given address
+func previousAddress(ip net.IP) net.IP {
+	previous := make(net.IP, len(ip))
+	copy(previous, ip)
+	for i := len(previous) - 1; i >= 0; i-- {
+		previous[i]--
+		if previous[i] != 0xff {
+			break
+		}
+	}
+	return previous
+}
@@ -0,0 +1,127 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+	"github.com/k-sone/snmpgo"
+)
+
+//Exchange read of OID from SNMP agent, single GET request in single mode or sequence of GETNEXT requests in walk and table modes
+type Exchange struct {
+	Agent    string
+	Oid      string
+	Mode     string
+	Start    time.Time
+	Duration time.Duration
+	Results  []*snmpgo.VarBind
+	Err      error
+
+	//Requests number of PDUs sent to SNMP agent in exchange, it is 0 if SNMP layer does not send single requests
+	Requests int
+}
+
+//Trace metrics returned by collection with exchanges with SNMP agents which are made in collection
+type Trace struct {
+	Metrics   []plugin.Metric
+	Exchanges []Exchange
+	Duration  time.Duration
+}
+
+//tracingSnmp records exchanges of SNMP layer of plugin
+type tracingSnmp struct {
+	snmp      snmpInterface
+	mtx       sync.Mutex
+	agents    map[*snmpgo.SNMP]string
+	exchanges []Exchange
+}
+
+func (t *tracingSnmp) newHandler(hostConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
+	handler, err := t.snmp.newHandler(hostConfig)
+	if err == nil {
+		t.mtx.Lock()
+		t.agents[handler] = hostConfig.Address
+		t.mtx.Unlock()
+	}
+	return handler, err
+}
+
+func (t *tracingSnmp) readElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	start := time.Now()
+	requests := 0
+	var results []*snmpgo.VarBind
+	var err error
+	if sender, ok := t.snmp.(requestSender); ok {
+		//PDUs are counted when they are sent, so the number is exact also when walk ends with error
+		results, err = snmp.Walk(func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+			requests++
+			return sender.sendRequest(handler, pduType, oid)
+		}, oid, mode)
+	} else {
+		results, err = t.snmp.readElements(handler, oid, mode)
+	}
+
+	t.mtx.Lock()
+	t.exchanges = append(t.exchanges, Exchange{Agent: t.agents[handler], Oid: oid, Mode: mode, Start: start,
+		Duration: time.Since(start), Results: results, Err: err, Requests: requests})
+	t.mtx.Unlock()
+	return results, err
+}
+
+func (t *tracingSnmp) closeHandler(handler *snmpgo.SNMP) {
+	t.mtx.Lock()
+	delete(t.agents, handler)
+	t.mtx.Unlock()
+	t.snmp.closeHandler(handler)
+}
+
+//TraceCollection collects metrics matching requested namespace from SNMP agent set in configuration and records exchanges
+//with SNMP agent, it replaces SNMP layer of plugin for time of collection so it is used only by debug commands
+func TraceCollection(config plugin.Config, namespace string) (Trace, error) {
+	tracer := &tracingSnmp{snmp: snmp_, agents: make(map[*snmpgo.SNMP]string)}
+	snmp_ = tracer
+	defer func() { snmp_ = tracer.snmp }()
+
+	start := time.Now()
+
+	//ranges are swept before collection, so metrics are returned from discovered SNMP agents in the first collection
+	discovery, ok, err := configReader.GetDiscoveryConfig(config)
+	if err != nil {
+		return Trace{}, err
+	}
+	if ok {
+		getDiscoverer(discovery).sweep(discovery)
+	}
+
+	mts, err := New().CollectMetrics([]plugin.Metric{{Namespace: plugin.NewNamespace(splitNamespace(namespace)...), Config: config}})
+	trace := Trace{Metrics: mts, Duration: time.Since(start)}
+
+	tracer.mtx.Lock()
+	trace.Exchanges = tracer.exchanges
+	tracer.mtx.Unlock()
+	return trace, err
+}
@@ -0,0 +1,236 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// sysDescrOid OID of sysDescr, it is read from discovered SNMP agents
+	sysDescrOid = ".1.3.6.1.2.1.1.1.0"
+
+	// sysNameOid OID of sysName, it is used as name of discovered SNMP agents
+	sysNameOid = ".1.3.6.1.2.1.1.5.0"
+
+	// maxDiscoveryWorkers maximal number of addresses which are probed at the same time
+	maxDiscoveryWorkers = 32
+)
+
+//target SNMP agent which is found in sweep of ranges
+type target struct {
+	//agentConfig configuration of SNMP agent with address of target and credential set accepted by target
+	agentConfig configReader.SnmpAgent
+
+	sysName     string
+	sysObjectID string
+	sysDescr    string
+
+	//profiles built-in profiles which are selected by sysObjectID
+	profiles []string
+}
+
+//discoverer keeps targets found in the last sweep of ranges, ranges are swept in background when discovery interval elapses
+type discoverer struct {
+	mtx       sync.Mutex
+	targets   map[string]target
+	lastSweep time.Time
+	sweeping  bool
+}
+
+var (
+	discoverers    = make(map[string]*discoverer)
+	mtxDiscoverers = &sync.Mutex{}
+)
+
+//getDiscoverer returns discoverer of ranges, the same discoverer is used only by tasks with the same configuration of discovery
+//(ranges, SNMP agent and credentials), otherwise a task could get targets probed with credentials of another task
+func getDiscoverer(config configReader.Discovery) *discoverer {
+	key := config.Key()
+
+	mtxDiscoverers.Lock()
+	defer mtxDiscoverers.Unlock()
+
+	d, ok := discoverers[key]
+	if !ok {
+		d = &discoverer{targets: make(map[string]target)}
+		discoverers[key] = d
+	}
+	return d
+}
+
+//getTargets returns targets found in the last sweep sorted by address, new sweep is started when discovery interval elapses
+func (d *discoverer) getTargets(config configReader.Discovery) []target {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if !d.sweeping && time.Since(d.lastSweep) >= time.Duration(config.Interval)*time.Second {
+		d.sweeping = true
+		go d.sweep(config)
+	}
+
+	addresses := []string{}
+	for address := range d.targets {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	targets := []target{}
+	for _, address := range addresses {
+		targets = append(targets, d.targets[address])
+	}
+	return targets
+}
+
+//sweep probes all addresses in ranges and replaces targets, SNMP agents which do not respond disappear from targets
+func (d *discoverer) sweep(config configReader.Discovery) {
+	targets := discover(config)
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	for address, t := range targets {
+		if _, ok := d.targets[address]; !ok {
+			log.WithFields(log.Fields{"agent_address": address, "sys_name": t.sysName, "sys_object_id": t.sysObjectID,
+				"profiles": t.profiles}).Info("SNMP agent discovered")
+		}
+	}
+	for address, t := range d.targets {
+		if _, ok := targets[address]; !ok {
+			log.WithFields(log.Fields{"agent_address": address, "sys_name": t.sysName}).Info("SNMP agent no longer responds")
+		}
+	}
+
+	d.targets = targets
+	d.lastSweep = time.Now()
+	d.sweeping = false
+}
+
+//discover probes addresses with rate limited to configured number of addresses per second and returns responsive SNMP agents
+func discover(config configReader.Discovery) map[string]target {
+	var mtxTargets sync.Mutex
+	var wgProbes sync.WaitGroup
+	targets := make(map[string]target)
+
+	ticker := time.NewTicker(time.Second / time.Duration(config.Rate))
+	defer ticker.Stop()
+
+	workers := make(chan struct{}, maxDiscoveryWorkers)
+	for i, address := range config.Addresses {
+		if i > 0 {
+			<-ticker.C
+		}
+		workers <- struct{}{}
+		wgProbes.Add(1)
+
+		go func(address string) {
+			defer wgProbes.Done()
+			defer func() { <-workers }()
+
+			if t, ok := probeTarget(config, address); ok {
+				mtxTargets.Lock()
+				targets[address] = t
+				mtxTargets.Unlock()
+			}
+		}(address)
+	}
+	wgProbes.Wait()
+	return targets
+}
+
+//probeTarget checks if SNMP agent responds at address using candidate credentials, the first accepted credential set is used for target
+func probeTarget(config configReader.Discovery, address string) (target, bool) {
+	credentialSets := config.Agent.CredentialSets
+	if len(credentialSets) == 0 {
+		credentialSets = []configReader.SnmpAgent{config.Agent}
+	}
+
+	for _, credentialSet := range credentialSets {
+		credentialSet.Address = address
+		handler, err := snmp_.newHandler(credentialSet)
+		if err != nil {
+			log.WithFields(log.Fields{"agent_address": address, "credential_set": credentialSet.CredentialSet}).Debug(err)
+			continue
+		}
+
+		//SNMP agent which responds with error accepts credential set
+		results, err := snmp_.readElements(handler, probeOid, configReader.ModeSingle)
+		if err != nil && !snmp.Responded(err) {
+			snmp_.closeHandler(handler)
+			continue
+		}
+
+		t := target{sysObjectID: scalarValue(results)}
+		if results, err = snmp_.readElements(handler, sysNameOid, configReader.ModeSingle); err == nil {
+			t.sysName = scalarValue(results)
+		}
+		if results, err = snmp_.readElements(handler, sysDescrOid, configReader.ModeSingle); err == nil {
+			t.sysDescr = scalarValue(results)
+		}
+		snmp_.closeHandler(handler)
+
+		t.agentConfig = config.Agent
+		t.agentConfig.Address = address
+		t.agentConfig.Name = t.sysName
+		if t.agentConfig.Name == "" {
+			t.agentConfig.Name = address
+		}
+		if len(config.Agent.CredentialSets) > 0 {
+			//accepted credential set is probed again when connection is opened
+			credentialSet.Name = t.agentConfig.Name
+			t.agentConfig.CredentialSet = credentialSet.CredentialSet
+			t.agentConfig.CredentialSets = []configReader.SnmpAgent{credentialSet}
+		}
+		t.profiles = config.ProfilesFor(t.sysObjectID)
+		return t, true
+	}
+	return target{}, false
+}
+
+//scalarValue returns value of scalar, it is empty if value is not available in SNMP agent
+func scalarValue(results []*snmpgo.VarBind) string {
+	if notAvailable(results, nil) {
+		return ""
+	}
+	return results[0].Variable.String()
+}
+
+//discoveredMetric checks if metric is collected from target, metrics of profiles which are selected by sysObjectID
+//are collected only from targets with matching sysObjectID
+func discoveredMetric(cfg configReader.Metric, t target, discoveryProfiles map[string]bool) bool {
+	if !discoveryProfiles[cfg.Profile] {
+		return true
+	}
+	for _, profile := range t.profiles {
+		if profile == cfg.Profile {
+			return true
+		}
+	}
+	return false
+}
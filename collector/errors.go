@@ -0,0 +1,144 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"sync"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	//errorCounts numbers of errors of reads from SNMP agents by address of SNMP agent and by category of error
+	errorCounts    = make(map[string]map[string]uint64)
+	mtxErrorCounts = &sync.Mutex{}
+)
+
+//collectionErrors errors of reads of metrics in collection from SNMP agent, it decides how the collection reacts to them:
+//  - timeout - the remaining reads are skipped (SNMP agent is not asked again in the collection),
+//  - auth - the remaining reads are skipped and the connection is closed, so the plugin authenticates again with SNMP agent in the next collection,
+//  - malformed - the read is retried once,
+//  - too_big - the error is logged at error level because it is not solved by retries,
+//  - agent - the metric is not collected.
+type collectionErrors struct {
+	mtx    sync.Mutex
+	counts map[string]uint64
+
+	//backoff indicates that SNMP agent does not respond or rejects credentials, reads of the remaining metrics are skipped
+	backoff bool
+
+	//reprobe indicates that credential sets need to be probed again, it is set when a request fails without response from SNMP agent
+	reprobe bool
+
+	//reauthenticate indicates that SNMP agent rejected credentials, the connection is opened again in the next collection
+	reauthenticate bool
+}
+
+func newCollectionErrors() *collectionErrors {
+	return &collectionErrors{counts: make(map[string]uint64)}
+}
+
+//add counts error of read and sets reaction of collection to it
+func (e *collectionErrors) add(err error) {
+	category := snmp.Category(err)
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.counts[category]++
+	if !snmp.Responded(err) {
+		e.reprobe = true
+	}
+	switch category {
+	case snmp.CategoryTimeout:
+		e.backoff = true
+	case snmp.CategoryAuth:
+		//the remaining requests with the same credentials would be rejected too
+		e.backoff = true
+		e.reauthenticate = true
+	}
+}
+
+//skip checks if reads are skipped in the rest of collection
+func (e *collectionErrors) skip() bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.backoff
+}
+
+//report adds numbers of errors in collection to numbers of errors of SNMP agent and logs them,
+//the log entry is the interface for monitoring of errors of SNMP agents (numbers of errors are not returned as metrics)
+func (e *collectionErrors) report(agentAddress string) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if len(e.counts) == 0 {
+		return
+	}
+
+	mtxErrorCounts.Lock()
+	if _, ok := errorCounts[agentAddress]; !ok {
+		errorCounts[agentAddress] = make(map[string]uint64)
+	}
+	fields := log.Fields{"agent_address": agentAddress}
+	for category, count := range e.counts {
+		errorCounts[agentAddress][category] += count
+		fields["errors_"+category] = count
+		fields["total_errors_"+category] = errorCounts[agentAddress][category]
+	}
+	mtxErrorCounts.Unlock()
+
+	log.WithFields(fields).Warn("Errors of SNMP agent in collection")
+}
+
+//readWithRetry reads metric, read is retried once if response of SNMP agent is malformed, all errors are counted
+func readWithRetry(conn *connection, cfg *configReader.Metric, errs *collectionErrors) ([]*snmpgo.VarBind, error) {
+	results, err := readMetric(conn.handler, cfg)
+	if _, ok := err.(*snmp.MalformedResponseError); ok {
+		errs.add(err)
+		log.WithFields(log.Fields{"OID": cfg.Oid}).Debug(err)
+		results, err = readMetric(conn.handler, cfg)
+	}
+	if err != nil {
+		errs.add(err)
+	}
+	return results, err
+}
+
+//ErrorCounts returns numbers of errors of reads of metrics from SNMP agents since start of plugin,
+//by address of SNMP agent and by category of error (see categories in snmp package)
+func ErrorCounts() map[string]map[string]uint64 {
+	mtxErrorCounts.Lock()
+	defer mtxErrorCounts.Unlock()
+
+	counts := make(map[string]map[string]uint64)
+	for agent, categories := range errorCounts {
+		counts[agent] = make(map[string]uint64)
+		for category, count := range categories {
+			counts[agent][category] = count
+		}
+	}
+	return counts
+}
@@ -0,0 +1,173 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-utilities/ns"
+)
+
+//decodeIndex decodes part of table index which starts at `start` element of OID (counting from 0) using given encoding,
+//encodings of index components are defined in RFC 2578 (section 7.7)
+func decodeIndex(oidParts []string, start uint, encoding string) (string, error) {
+	if start >= uint(len(oidParts)) {
+		return "", fmt.Errorf("Incorrect value of `oid_part` (%d) in configuration of namespace, OID has %d elements", start, len(oidParts))
+	}
+
+	subIds, err := parseSubIds(oidParts[start:])
+	if err != nil {
+		return "", err
+	}
+
+	switch encoding {
+	case "", configReader.IndexEncodingInteger:
+		return strconv.FormatUint(subIds[0], 10), nil
+
+	case configReader.IndexEncodingIPv4:
+		octets, err := fixedLengthIndex(subIds, net.IPv4len, encoding)
+		if err != nil {
+			return "", err
+		}
+		return net.IP(octets).String(), nil
+
+	case configReader.IndexEncodingIPv6:
+		octets, err := fixedLengthIndex(subIds, net.IPv6len, encoding)
+		if err != nil {
+			return "", err
+		}
+		return net.IP(octets).String(), nil
+
+	case configReader.IndexEncodingMAC:
+		octets, err := fixedLengthIndex(subIds, 6, encoding)
+		if err != nil {
+			return "", err
+		}
+		return net.HardwareAddr(octets).String(), nil
+
+	case configReader.IndexEncodingInetAddress:
+		//InetAddressType followed by length-prefixed InetAddress (RFC 4001)
+		octets, err := lengthPrefixedIndex(subIds[1:], encoding)
+		if err != nil {
+			return "", err
+		}
+		return formatInetAddress(subIds[0], octets)
+
+	case configReader.IndexEncodingString:
+		octets, err := lengthPrefixedIndex(subIds, encoding)
+		if err != nil {
+			return "", err
+		}
+		return formatOctetString(octets), nil
+
+	case configReader.IndexEncodingImpliedString:
+		octets, err := toOctets(subIds, encoding)
+		if err != nil {
+			return "", err
+		}
+		return formatOctetString(octets), nil
+
+	case configReader.IndexEncodingOid:
+		if subIds[0] > uint64(len(subIds)-1) {
+			return "", fmt.Errorf("Incorrect length (%d) of index encoded as %s, only %d OID elements left", subIds[0], encoding, len(subIds)-1)
+		}
+		return strings.Join(oidParts[start+1:start+1+uint(subIds[0])], "."), nil
+
+	case configReader.IndexEncodingImpliedOid:
+		return strings.Join(oidParts[start:], "."), nil
+	}
+	return "", fmt.Errorf("Unknown encoding of index (%s)", encoding)
+}
+
+//parseSubIds converts elements of OID to numbers
+func parseSubIds(oidParts []string) ([]uint64, error) {
+	subIds := make([]uint64, len(oidParts))
+	for i, part := range oidParts {
+		subId, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Incorrect element of OID (%s): %v", part, err)
+		}
+		subIds[i] = subId
+	}
+	return subIds, nil
+}
+
+//fixedLengthIndex returns octets of index component with fixed length
+func fixedLengthIndex(subIds []uint64, length int, encoding string) ([]byte, error) {
+	if len(subIds) < length {
+		return nil, fmt.Errorf("Index encoded as %s needs %d OID elements, only %d OID elements left", encoding, length, len(subIds))
+	}
+	return toOctets(subIds[:length], encoding)
+}
+
+//lengthPrefixedIndex returns octets of index component which is preceded by its length
+func lengthPrefixedIndex(subIds []uint64, encoding string) ([]byte, error) {
+	if len(subIds) == 0 {
+		return nil, fmt.Errorf("Missing length of index encoded as %s", encoding)
+	}
+	if subIds[0] > uint64(len(subIds)-1) {
+		return nil, fmt.Errorf("Incorrect length (%d) of index encoded as %s, only %d OID elements left", subIds[0], encoding, len(subIds)-1)
+	}
+	return toOctets(subIds[1:1+subIds[0]], encoding)
+}
+
+//toOctets converts OID elements to octets, each element must be in range 0-255
+func toOctets(subIds []uint64, encoding string) ([]byte, error) {
+	octets := make([]byte, len(subIds))
+	for i, subId := range subIds {
+		if subId > 255 {
+			return nil, fmt.Errorf("Incorrect element of index encoded as %s (%d), it is greater than 255", encoding, subId)
+		}
+		octets[i] = byte(subId)
+	}
+	return octets, nil
+}
+
+//formatInetAddress formats InetAddress according to InetAddressType (RFC 4001)
+func formatInetAddress(addressType uint64, octets []byte) (string, error) {
+	switch {
+	case addressType == 1 && len(octets) == net.IPv4len, addressType == 2 && len(octets) == net.IPv6len:
+		//ipv4, ipv6
+		return net.IP(octets).String(), nil
+	case addressType == 3 && len(octets) == net.IPv4len+4, addressType == 4 && len(octets) == net.IPv6len+4:
+		//ipv4z, ipv6z - address followed by zone index
+		ipLen := len(octets) - 4
+		zone := uint32(octets[ipLen])<<24 | uint32(octets[ipLen+1])<<16 | uint32(octets[ipLen+2])<<8 | uint32(octets[ipLen+3])
+		return fmt.Sprintf("%s%%%d", net.IP(octets[:ipLen]), zone), nil
+	case addressType == 16:
+		//dns
+		return formatOctetString(octets), nil
+	}
+	return "", fmt.Errorf("Incorrect InetAddress in index, type %d and length %d", addressType, len(octets))
+}
+
+//formatOctetString returns printable string, non-printable strings are returned as hexadecimal octets
+func formatOctetString(octets []byte) string {
+	if !isPrintable(octets) {
+		return hexOctets(octets)
+	}
+	return ns.ReplaceNotAllowedCharsInNamespacePart(string(octets))
+}
@@ -0,0 +1,328 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+//tableCache caches columns of tables which are read to resolve joins, it is shared by metrics collected from SNMP agent in one collection,
+//results which are cached between collections are taken from label cache of connection
+type tableCache struct {
+	mtx     sync.Mutex
+	columns map[string]map[string]snmpgo.Variable
+
+	//labels cache of connection, nil if results are not cached between collections
+	labels           *labelCache
+	sysUpTimeChecked bool
+
+	//sysUpTime is read once in collection, it is shared by label cache and rates of metrics
+	sysUpTimeOnce sync.Once
+	sysUpTime     uint64
+	sysUpTimeRead bool
+}
+
+//join describes column of other table which is joined with metric table
+type join struct {
+	oid      string
+	join     string
+	indexOid string
+	oidPart  uint
+
+	cacheTTL           uint
+	cacheInvalidateOid string
+
+	//fallbackOid column which is read if value is not available in SNMP agent or it is empty
+	fallbackOid string
+
+	//subtypeOid column with the same index which contains subtype of value, subtypeEncoding maps subtypes to encodings of value
+	subtypeOid      string
+	subtypeEncoding map[string]string
+}
+
+func newTableCache(labels *labelCache) *tableCache {
+	return &tableCache{columns: make(map[string]map[string]snmpgo.Variable), labels: labels}
+}
+
+//read reads elements of OID, if cacheTTL is set then results are taken from label cache until they expire or they are invalidated
+func (c *tableCache) read(handler *snmpgo.SNMP, oid string, mode string, cacheTTL uint, cacheInvalidateOid string) ([]*snmpgo.VarBind, error) {
+	if cacheTTL == 0 || c.labels == nil {
+		return snmp_.readElements(handler, oid, mode)
+	}
+
+	c.checkSysUpTime(handler)
+
+	triggerValue := ""
+	if cacheInvalidateOid != "" {
+		trigger, err := c.column(handler, cacheInvalidateOid, configReader.ModeSingle, 0, "")
+		if err != nil {
+			return nil, err
+		}
+		if value, ok := trigger[""]; ok {
+			triggerValue = value.String()
+		}
+	}
+
+	if results, ok := c.labels.get(oid, mode, triggerValue); ok {
+		return results, nil
+	}
+
+	results, err := snmp_.readElements(handler, oid, mode)
+	if err != nil {
+		return nil, err
+	}
+	c.labels.set(oid, mode, results, cacheTTL, cacheInvalidateOid, triggerValue)
+	return results, nil
+}
+
+//invalidate removes results of OID from caches
+func (c *tableCache) invalidate(oid string, mode string) {
+	c.mtx.Lock()
+	delete(c.columns, strings.Trim(oid, "."))
+	c.mtx.Unlock()
+
+	if c.labels != nil {
+		c.labels.invalidate(oid, mode)
+	}
+}
+
+//checkSysUpTime checks sysUpTime once in collection to detect restart of SNMP agent
+func (c *tableCache) checkSysUpTime(handler *snmpgo.SNMP) {
+	c.mtx.Lock()
+	checked := c.sysUpTimeChecked
+	c.sysUpTimeChecked = true
+	c.mtx.Unlock()
+
+	if checked {
+		return
+	}
+
+	if ticks, ok := c.uptime(handler); ok {
+		c.labels.checkSysUpTime(ticks)
+	}
+}
+
+//uptime returns value of sysUpTime which is read once in collection, false is returned if it cannot be read
+func (c *tableCache) uptime(handler *snmpgo.SNMP) (uint64, bool) {
+	c.sysUpTimeOnce.Do(func() {
+		results, err := snmp_.readElements(handler, sysUpTimeOid, configReader.ModeSingle)
+		if err != nil || len(results) == 0 {
+			log.WithFields(log.Fields{"OID": sysUpTimeOid}).Warn(fmt.Errorf("Cannot read sysUpTime to detect restart of SNMP agent: %v", err))
+			return
+		}
+
+		ticks, err := strconv.ParseUint(results[0].Variable.String(), 10, 64)
+		if err != nil {
+			log.WithFields(log.Fields{"sysUpTime": results[0].Variable.String()}).Warn(err)
+			return
+		}
+		c.sysUpTime, c.sysUpTimeRead = ticks, true
+	})
+	return c.sysUpTime, c.sysUpTimeRead
+}
+
+//column returns values of column (or value of scalar if mode is single) indexed by remaining part of OID,
+//column is read only once in collection and then it is taken from cache
+func (c *tableCache) column(handler *snmpgo.SNMP, oid string, mode string, cacheTTL uint, cacheInvalidateOid string) (map[string]snmpgo.Variable, error) {
+	key := strings.Trim(oid, ".")
+
+	c.mtx.Lock()
+	column, ok := c.columns[key]
+	c.mtx.Unlock()
+	if ok {
+		return column, nil
+	}
+
+	results, err := c.read(handler, oid, mode, cacheTTL, cacheInvalidateOid)
+	if err != nil {
+		return nil, err
+	}
+
+	column = make(map[string]snmpgo.Variable)
+	for _, result := range results {
+		index := strings.TrimPrefix(strings.TrimPrefix(strings.Trim(result.Oid.String(), "."), key), ".")
+		column[index] = result.Variable
+	}
+
+	c.mtx.Lock()
+	c.columns[key] = column
+	c.mtx.Unlock()
+	return column, nil
+}
+
+//resolveJoin returns values of joined column for each of results,
+//join without type is used for tags and it means that the same scalar value is used for all results
+func resolveJoin(handler *snmpgo.SNMP, cache *tableCache, results []*snmpgo.VarBind, metric *configReader.Metric, j join) ([]string, error) {
+	values, missing, err := lookupJoin(handler, cache, results, metric, j)
+	if missing && j.cacheTTL > 0 {
+		//cached columns can be outdated if index is missing, so they are read again
+		cache.invalidate(j.oid, configReader.ModeWalk)
+		if j.fallbackOid != "" {
+			cache.invalidate(j.fallbackOid, configReader.ModeWalk)
+		}
+		if j.subtypeOid != "" {
+			cache.invalidate(j.subtypeOid, configReader.ModeWalk)
+		}
+		if j.join == configReader.JoinPointer {
+			cache.invalidate(j.indexOid, configReader.ModeWalk)
+		}
+		values, _, err = lookupJoin(handler, cache, results, metric, j)
+	}
+	return values, err
+}
+
+//lookupJoin looks up values of joined column for each of results, it also indicates if index is missing in joined column
+func lookupJoin(handler *snmpgo.SNMP, cache *tableCache, results []*snmpgo.VarBind, metric *configReader.Metric, j join) ([]string, bool, error) {
+	values := []string{}
+
+	if j.join == "" {
+		scalar, err := cache.column(handler, j.oid, configReader.ModeSingle, j.cacheTTL, j.cacheInvalidateOid)
+		if err != nil {
+			return nil, false, err
+		}
+		value, ok := scalar[""]
+		if !ok {
+			return nil, false, fmt.Errorf("Cannot read value of scalar (%s)", j.oid)
+		}
+		for range results {
+			values = append(values, value.String())
+		}
+		return values, false, nil
+	}
+
+	column, err := cache.column(handler, j.oid, configReader.ModeWalk, j.cacheTTL, j.cacheInvalidateOid)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var pointers, fallback, subtypes map[string]snmpgo.Variable
+	if j.join == configReader.JoinPointer {
+		pointers, err = cache.column(handler, j.indexOid, configReader.ModeWalk, j.cacheTTL, j.cacheInvalidateOid)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for _, result := range results {
+		index, err := rowIndex(result.Oid.String(), metric, j.oidPart)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if j.join == configReader.JoinPointer {
+			pointer, ok := pointers[index]
+			if !ok {
+				return nil, true, fmt.Errorf("Cannot find value of pointer column (%s) for index (%s)", j.indexOid, index)
+			}
+			index = pointer.String()
+		}
+
+		value, ok := column[index]
+		if (!ok || value.String() == "") && j.fallbackOid != "" {
+			//fallback column is read if value is not available or it is empty
+			if fallback == nil {
+				fallback, err = cache.column(handler, j.fallbackOid, configReader.ModeWalk, j.cacheTTL, j.cacheInvalidateOid)
+				if err != nil {
+					return nil, false, err
+				}
+			}
+			if fallbackValue, found := fallback[index]; found {
+				value, ok = fallbackValue, true
+			}
+		}
+		if !ok {
+			return nil, true, fmt.Errorf("Cannot find value of joined column (%s) for index (%s)", j.oid, index)
+		}
+
+		if j.subtypeOid == "" {
+			values = append(values, value.String())
+			continue
+		}
+
+		//value is decoded according to its subtype which is read from column with the same index
+		if subtypes == nil {
+			subtypes, err = cache.column(handler, j.subtypeOid, configReader.ModeWalk, j.cacheTTL, j.cacheInvalidateOid)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		subtype, ok := subtypes[index]
+		if !ok {
+			return nil, true, fmt.Errorf("Cannot find subtype (%s) for index (%s)", j.subtypeOid, index)
+		}
+		values = append(values, decodeValue(value, j.subtypeEncoding[subtype.String()]))
+	}
+	return values, false, nil
+}
+
+//rowIndex returns index of metric table row, it is a part of OID after column OID or a single OID element (`oid_part`) which is a foreign index
+func rowIndex(oid string, metric *configReader.Metric, oidPart uint) (string, error) {
+	oidParts := strings.Split(strings.Trim(oid, "."), ".")
+
+	if oidPart > 0 {
+		if oidPart >= uint(len(oidParts)) {
+			return "", fmt.Errorf("Incorrect value of `oid_part` (%d) in configuration of join, OID (%s) has %d elements", oidPart, oid, len(oidParts))
+		}
+		return oidParts[oidPart], nil
+	}
+
+	start := len(strings.Split(strings.Trim(metric.Oid, "."), "."))
+	if metric.Mode == configReader.ModeWalk {
+		//in walk mode OID of metric is OID of table entry, so column is skipped
+		start++
+	}
+
+	if start >= len(oidParts) {
+		return "", fmt.Errorf("Cannot find index of row in OID (%s), `oid_part` must be set in configuration of join", oid)
+	}
+	return strings.Join(oidParts[start:], "."), nil
+}
+
+//getTags gets values of tags configured for metric, tags are returned for each of results,
+//tags which cannot be read are skipped and metrics are returned without them
+func getTags(handler *snmpgo.SNMP, cache *tableCache, results []*snmpgo.VarBind, metric *configReader.Metric) []map[string]string {
+	tags := make([]map[string]string, len(results))
+	for i := range tags {
+		tags[i] = make(map[string]string)
+	}
+
+	for _, tag := range metric.Tags {
+		values, err := resolveJoin(handler, cache, results, metric, join{oid: tag.Oid, join: tag.Join, indexOid: tag.IndexOid,
+			oidPart: tag.OidPart, cacheTTL: tag.CacheTTL, cacheInvalidateOid: tag.CacheInvalidateOid,
+			subtypeOid: tag.SubtypeOid, subtypeEncoding: tag.SubtypeEncoding})
+		if err != nil {
+			log.WithFields(log.Fields{"tag": tag.Name, "OID": tag.Oid}).Warn(err)
+			continue
+		}
+		for i, value := range values {
+			tags[i][tag.Name] = mapValue(tag.ValueMap, value)
+		}
+	}
+	return tags
+}
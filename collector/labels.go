@@ -0,0 +1,107 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// sysUpTimeOid OID of sysUpTime, its reset means that SNMP agent was restarted and cached labels are invalidated
+	sysUpTimeOid = ".1.3.6.1.2.1.1.3.0"
+)
+
+//labelCache caches results of SNMP requests which are used as namespace elements and tags between collections,
+//it is kept with connection to SNMP agent
+type labelCache struct {
+	mtx       sync.Mutex
+	entries   map[string]labelCacheEntry
+	sysUpTime uint64
+}
+
+type labelCacheEntry struct {
+	results []*snmpgo.VarBind
+	expires time.Time
+
+	//triggerOid OID which value is compared with triggerValue, cache entry is invalidated when value changes
+	triggerOid   string
+	triggerValue string
+}
+
+func newLabelCache() *labelCache {
+	return &labelCache{entries: make(map[string]labelCacheEntry)}
+}
+
+//labelCacheKey returns key of cache entry, the same OID can be read in different modes
+func labelCacheKey(oid string, mode string) string {
+	return mode + ":" + strings.Trim(oid, ".")
+}
+
+//get returns cached results if they are not expired and value of trigger OID has not changed
+func (l *labelCache) get(oid string, mode string, triggerValue string) ([]*snmpgo.VarBind, bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	entry, ok := l.entries[labelCacheKey(oid, mode)]
+	if !ok || time.Now().After(entry.expires) || entry.triggerValue != triggerValue {
+		return nil, false
+	}
+	return entry.results, true
+}
+
+//set caches results for ttl seconds
+func (l *labelCache) set(oid string, mode string, results []*snmpgo.VarBind, ttl uint, triggerOid string, triggerValue string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.entries[labelCacheKey(oid, mode)] = labelCacheEntry{
+		results:      results,
+		expires:      time.Now().Add(time.Duration(ttl) * time.Second),
+		triggerOid:   triggerOid,
+		triggerValue: triggerValue,
+	}
+}
+
+//invalidate removes cached results of OID
+func (l *labelCache) invalidate(oid string, mode string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	delete(l.entries, labelCacheKey(oid, mode))
+}
+
+//checkSysUpTime invalidates all cached results if sysUpTime is lower than previously read value (SNMP agent was restarted)
+func (l *labelCache) checkSysUpTime(ticks uint64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if ticks < l.sysUpTime {
+		log.WithFields(log.Fields{"previous_sysUpTime": l.sysUpTime, "current_sysUpTime": ticks}).Debug("SNMP agent restarted, cached labels are invalidated")
+		l.entries = make(map[string]labelCacheEntry)
+	}
+	l.sysUpTime = ticks
+}
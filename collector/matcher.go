@@ -0,0 +1,106 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+)
+
+const (
+	// wildcard namespace element which matches any element
+	wildcard = "*"
+)
+
+//namespaceMatcher matches namespaces element by element, element of requested namespace can be `*` (any element)
+//or tuple `(a|b)` (one of listed elements), namespaces must have the same number of elements unless the last element
+//of requested namespace is `*` which matches all namespaces with given prefix (e.g. `/intel/snmp/*`)
+type namespaceMatcher struct {
+	namespace string
+	elements  []map[string]bool
+	prefix    bool
+}
+
+//requestedConfig configuration of metric with requested metrics which select it and their matchers
+type requestedConfig struct {
+	cfg      configReader.Metric
+	metrics  []plugin.Metric
+	matchers []namespaceMatcher
+}
+
+//newNamespaceMatcher compiles requested namespace
+func newNamespaceMatcher(elements []string) namespaceMatcher {
+	m := namespaceMatcher{namespace: "/" + strings.Join(elements, "/"), elements: make([]map[string]bool, len(elements))}
+	m.prefix = len(elements) > 0 && elements[len(elements)-1] == wildcard
+	for i, element := range elements {
+		if element == wildcard {
+			//nil matches any element
+			continue
+		}
+		m.elements[i] = map[string]bool{}
+		for _, option := range tupleOptions(element) {
+			m.elements[i][option] = true
+		}
+	}
+	return m
+}
+
+//tupleOptions returns elements listed in tuple, Snap separates elements of tuple with `|` or `;`
+func tupleOptions(element string) []string {
+	if len(element) < 2 || !strings.HasPrefix(element, "(") || !strings.HasSuffix(element, ")") {
+		return []string{element}
+	}
+	return strings.FieldsFunc(element[1:len(element)-1], func(r rune) bool { return r == '|' || r == ';' })
+}
+
+//match checks if namespace matches requested namespace, `*` in namespace is a dynamic element which matches any requested element
+func (m namespaceMatcher) match(namespace []string) bool {
+	if len(namespace) != len(m.elements) && !(m.prefix && len(namespace) > len(m.elements)) {
+		return false
+	}
+	for i, element := range namespace[:len(m.elements)] {
+		if m.elements[i] != nil && element != wildcard && !m.elements[i][element] {
+			return false
+		}
+	}
+	return true
+}
+
+//requestedBy returns the first requested metric which matches namespace of collected metric
+func (r *requestedConfig) requestedBy(namespace []string) (plugin.Metric, bool) {
+	for i, matcher := range r.matchers {
+		if matcher.match(namespace) {
+			return r.metrics[i], true
+		}
+	}
+	return plugin.Metric{}, false
+}
+
+//splitNamespace splits namespace string into elements, the first character of namespace string is separator of elements
+func splitNamespace(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return strings.Split(namespace[1:], namespace[:1])
+}
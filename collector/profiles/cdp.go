@@ -0,0 +1,81 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+//cdp profile of neighbors discovered by Cisco Discovery Protocol (CISCO-CDP-MIB)
+const cdp = `[
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "cdp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "interface", "description": "local interface (ifName, ifDescr if ifName is not available)", "OID": ".1.3.6.1.2.1.31.1.1.1.1", "fallback_OID": ".1.3.6.1.2.1.2.2.1.2", "join": "index", "oid_part": 14, "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local interface (cdpCacheDeviceIndex)", "oid_part": 15},
+      {"source": "string", "string": "device_id"}
+    ],
+    "OID": ".1.3.6.1.4.1.9.9.23.1.2.1.1.6",
+    "unit": "",
+    "description": "identifier of neighbor (cdpCacheDeviceId)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "cdp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "interface", "description": "local interface (ifName, ifDescr if ifName is not available)", "OID": ".1.3.6.1.2.1.31.1.1.1.1", "fallback_OID": ".1.3.6.1.2.1.2.2.1.2", "join": "index", "oid_part": 14, "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local interface (cdpCacheDeviceIndex)", "oid_part": 15},
+      {"source": "string", "string": "device_port"}
+    ],
+    "OID": ".1.3.6.1.4.1.9.9.23.1.2.1.1.7",
+    "unit": "",
+    "description": "port of neighbor (cdpCacheDevicePort)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "cdp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "interface", "description": "local interface (ifName, ifDescr if ifName is not available)", "OID": ".1.3.6.1.2.1.31.1.1.1.1", "fallback_OID": ".1.3.6.1.2.1.2.2.1.2", "join": "index", "oid_part": 14, "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local interface (cdpCacheDeviceIndex)", "oid_part": 15},
+      {"source": "string", "string": "platform"}
+    ],
+    "OID": ".1.3.6.1.4.1.9.9.23.1.2.1.1.8",
+    "unit": "",
+    "description": "hardware platform of neighbor (cdpCachePlatform)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "cdp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "interface", "description": "local interface (ifName, ifDescr if ifName is not available)", "OID": ".1.3.6.1.2.1.31.1.1.1.1", "fallback_OID": ".1.3.6.1.2.1.2.2.1.2", "join": "index", "oid_part": 14, "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local interface (cdpCacheDeviceIndex)", "oid_part": 15},
+      {"source": "string", "string": "address"}
+    ],
+    "OID": ".1.3.6.1.4.1.9.9.23.1.2.1.1.4",
+    "subtype_OID": ".1.3.6.1.4.1.9.9.23.1.2.1.1.3",
+    "subtype_encoding": {"1": "ip", "20": "ip"},
+    "unit": "",
+    "description": "address of neighbor (cdpCacheAddress) decoded according to cdpCacheAddressType"
+  }
+]
+`
@@ -0,0 +1,93 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+//entityElement namespace element of physical entity, shared by sensor and state metrics
+const entityElement = `{"source": "snmp", "name": "entity", "description": "name of physical entity (entPhysicalName, entPhysicalDescr if name is empty)", "OID": ".1.3.6.1.2.1.47.1.1.1.1.7", "fallback_OID": ".1.3.6.1.2.1.47.1.1.1.1.2", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.47.1.4.1.0"}`
+
+//sensorNamespace namespace elements of sensor metrics (entity/sensor/<type>/<entity>)
+const sensorNamespace = `{"source": "string", "string": "entity"},
+      {"source": "string", "string": "sensor"},
+      {"source": "snmp", "name": "type", "description": "type of sensor (entPhySensorType)", "OID": ".1.3.6.1.2.1.99.1.1.1.1", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.47.1.4.1.0", "value_map": {"1": "other", "2": "unknown", "3": "volts_ac", "4": "volts_dc", "5": "amperes", "6": "watts", "7": "hertz", "8": "celsius", "9": "percent_rh", "10": "rpm", "11": "cmm", "12": "truthvalue", "13": "special_enum", "14": "dbm"}},
+      ` + entityElement
+
+//stateNamespace namespace elements of state metrics (entity/state/<entity>)
+const stateNamespace = `{"source": "string", "string": "entity"},
+      {"source": "string", "string": "state"},
+      ` + entityElement
+
+//stateTags tags of state metrics, class of physical entity is mapped from enumeration of ENTITY-MIB
+const stateTags = `[
+      {"name": "entPhysicalClass", "OID": ".1.3.6.1.2.1.47.1.1.1.1.5", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.47.1.4.1.0", "value_map": {"1": "other", "2": "unknown", "3": "chassis", "4": "backplane", "5": "container", "6": "power_supply", "7": "fan", "8": "sensor", "9": "module", "10": "port", "11": "stack", "12": "cpu", "13": "energy_object", "14": "battery", "15": "storage_drive"}}
+    ]`
+
+//sensorMetrics sensor metrics, name is the last element of namespace and definition contains parameters of metric (in setfile format)
+//except namespace which is the same for all sensor metrics
+var sensorMetrics = []struct {
+	name       string
+	definition string
+}{
+	{"value", `"OID": ".1.3.6.1.2.1.99.1.1.1.4", "scale_OID": ".1.3.6.1.2.1.99.1.1.1.2", "precision_OID": ".1.3.6.1.2.1.99.1.1.1.3", "tags": [{"name": "unit", "OID": ".1.3.6.1.2.1.99.1.1.1.1", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.47.1.4.1.0", "value_map": {"1": "", "2": "", "3": "V", "4": "V", "5": "A", "6": "W", "7": "Hz", "8": "C", "9": "%", "10": "rpm", "11": "cmm", "12": "", "13": "", "14": "dBm"}}, {"name": "entPhySensorUnitsDisplay", "OID": ".1.3.6.1.2.1.99.1.1.1.6", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.47.1.4.1.0"}], "unit": "", "description": "value of sensor (entPhySensorValue) scaled by entPhySensorScale and entPhySensorPrecision, unit is added as tag"`},
+	{"oper_status", `"OID": ".1.3.6.1.2.1.99.1.1.1.5", "unit": "", "description": "operational status of sensor (1 - ok, 2 - unavailable, 3 - nonoperational)"`},
+}
+
+//stateMetrics state metrics, definition does not contain namespace and tags which are the same for all state metrics
+var stateMetrics = []struct {
+	name       string
+	definition string
+}{
+	{"oper_status", `"OID": ".1.3.6.1.2.1.131.1.1.1.3", "unit": "", "description": "operational state of physical entity, e.g. power supply (entStateOper: 1 - unknown, 2 - disabled, 3 - enabled, 4 - testing)"`},
+	{"admin_status", `"OID": ".1.3.6.1.2.1.131.1.1.1.2", "unit": "", "description": "administrative state of physical entity (entStateAdmin: 1 - unknown, 2 - locked, 3 - shuttingDown, 4 - unlocked)"`},
+}
+
+//entity profile of hardware health, sensors are read from ENTITY-SENSOR-MIB (RFC 3433) and states of physical entities
+//(e.g. power supplies) are read from ENTITY-STATE-MIB (RFC 4268), entities are named by ENTITY-MIB (RFC 6933)
+var entity = entityProfile()
+
+//entityProfile builds definitions of sensor and state metrics from shared namespace elements and tags
+func entityProfile() string {
+	definitions := []string{}
+	for _, metric := range sensorMetrics {
+		definitions = append(definitions, entityMetric(sensorNamespace, metric.name, metric.definition))
+	}
+	for _, metric := range stateMetrics {
+		definitions = append(definitions, entityMetric(stateNamespace, metric.name, metric.definition+`, "tags": `+stateTags))
+	}
+	return "[\n" + strings.Join(definitions, ",\n") + "\n]\n"
+}
+
+//entityMetric returns definition of metric in table mode with namespace ended by name of metric
+func entityMetric(namespace, name, definition string) string {
+	return fmt.Sprintf(`  {
+    "mode": "table",
+    "namespace": [
+      %s,
+      {"source": "string", "string": "%s"}
+    ],
+    %s
+  }`, namespace, name, definition)
+}
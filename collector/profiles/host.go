@@ -0,0 +1,203 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+//storageNamespace namespace elements of storage metrics (host/storage/<storage>)
+const storageNamespace = `{"source": "string", "string": "host"},
+      {"source": "string", "string": "storage"},
+      {"source": "snmp", "name": "storage", "description": "description of storage area (hrStorageDescr)", "OID": ".1.3.6.1.2.1.25.2.3.1.3", "join": "index", "cache_ttl": 600}`
+
+//storageTags tags of storage metrics, type of storage area is mapped from OIDs of hrStorageTypes
+const storageTags = `[
+      {"name": "hrStorageType", "OID": ".1.3.6.1.2.1.25.2.3.1.2", "join": "index", "cache_ttl": 600, "value_map": {".1.3.6.1.2.1.25.2.1.1": "other", ".1.3.6.1.2.1.25.2.1.2": "ram", ".1.3.6.1.2.1.25.2.1.3": "virtual_memory", ".1.3.6.1.2.1.25.2.1.4": "fixed_disk", ".1.3.6.1.2.1.25.2.1.5": "removable_disk", ".1.3.6.1.2.1.25.2.1.6": "floppy_disk", ".1.3.6.1.2.1.25.2.1.7": "compact_disc", ".1.3.6.1.2.1.25.2.1.8": "ram_disk", ".1.3.6.1.2.1.25.2.1.9": "flash_memory", ".1.3.6.1.2.1.25.2.1.10": "network_disk"}}
+    ]`
+
+//storageMetrics storage metrics, name is the last element of namespace and definition contains parameters of metric (in setfile format)
+//except namespace and tags which are the same for all storage metrics
+var storageMetrics = []struct {
+	name       string
+	definition string
+}{
+	{"size_bytes", `"OID": ".1.3.6.1.2.1.25.2.3.1.5", "multiply_by_OID": ".1.3.6.1.2.1.25.2.3.1.4", "unit": "B", "description": "size of storage area (hrStorageSize multiplied by hrStorageAllocationUnits)"`},
+	{"used_bytes", `"OID": ".1.3.6.1.2.1.25.2.3.1.6", "multiply_by_OID": ".1.3.6.1.2.1.25.2.3.1.4", "unit": "B", "description": "used space of storage area (hrStorageUsed multiplied by hrStorageAllocationUnits)"`},
+	{"used_percent", `"OID": ".1.3.6.1.2.1.25.2.3.1.6", "divide_by_OID": ".1.3.6.1.2.1.25.2.3.1.5", "scale": 100, "unit": "%", "description": "used space of storage area in percent (hrStorageUsed divided by hrStorageSize)"`},
+}
+
+//hostMetrics metrics of processors, load averages and memory
+const hostMetrics = `  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "cpu"},
+      {"source": "index", "name": "cpu", "description": "index of processor device (hrDeviceIndex)", "oid_part": 11},
+      {"source": "string", "string": "load_percent"}
+    ],
+    "OID": ".1.3.6.1.2.1.25.3.3.1.2",
+    "unit": "%",
+    "description": "average load of processor over the last minute (hrProcessorLoad)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "load"},
+      {"source": "snmp", "name": "period", "description": "period of load average (1min, 5min, 15min)", "OID": ".1.3.6.1.4.1.2021.10.1.2", "join": "index", "cache_ttl": 3600, "value_map": {"Load-1": "1min", "Load-5": "5min", "Load-15": "15min"}},
+      {"source": "string", "string": "average"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.10.1.5",
+    "scale": 0.01,
+    "unit": "",
+    "description": "load average (laLoadInt divided by 100)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "memory"},
+      {"source": "string", "string": "total_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.5.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "total amount of real memory (memTotalReal)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "memory"},
+      {"source": "string", "string": "available_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.6.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "amount of real memory which is not used (memAvailReal)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "memory"},
+      {"source": "string", "string": "shared_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.13.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "amount of real memory used as shared memory (memShared)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "memory"},
+      {"source": "string", "string": "buffer_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.14.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "amount of real memory used as memory buffers (memBuffer)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "memory"},
+      {"source": "string", "string": "cached_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.15.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "amount of real memory used as cache (memCached)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "swap"},
+      {"source": "string", "string": "total_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.3.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "total amount of swap space (memTotalSwap)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "swap"},
+      {"source": "string", "string": "available_bytes"}
+    ],
+    "OID": ".1.3.6.1.4.1.2021.4.4.0",
+    "scale": 1024,
+    "unit": "B",
+    "description": "amount of swap space which is not used (memAvailSwap)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "processes"},
+      {"source": "string", "string": "count"}
+    ],
+    "OID": ".1.3.6.1.2.1.25.1.6.0",
+    "unit": "",
+    "description": "number of process contexts currently loaded or running (hrSystemProcesses)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "host"},
+      {"source": "string", "string": "users"},
+      {"source": "string", "string": "count"}
+    ],
+    "OID": ".1.3.6.1.2.1.25.1.5.0",
+    "unit": "",
+    "description": "number of user sessions (hrSystemNumUsers)"
+  }`
+
+//host profile of server metrics, storage and processors are read from HOST-RESOURCES-MIB (RFC 2790),
+//load averages and memory are read from UCD-SNMP-MIB (net-snmp)
+var host = hostProfile()
+
+//hostProfile builds definitions of storage metrics from namespace and tags shared by all of them, followed by the other host metrics
+func hostProfile() string {
+	definitions := []string{}
+	for _, metric := range storageMetrics {
+		definitions = append(definitions, fmt.Sprintf(`  {
+    "mode": "table",
+    "namespace": [
+      %s,
+      {"source": "string", "string": "%s"}
+    ],
+    %s,
+    "tags": %s
+  }`, storageNamespace, metric.name, metric.definition, storageTags))
+	}
+	definitions = append(definitions, hostMetrics)
+	return "[\n" + strings.Join(definitions, ",\n") + "\n]\n"
+}
@@ -0,0 +1,86 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+//ifNamespace namespace elements of interface metrics (if/<interface>), cached interface names are invalidated by ifTableLastChange
+const ifNamespace = `{"source": "string", "string": "if"},
+      {"source": "snmp", "name": "interface", "description": "interface name (ifName, ifDescr if ifName is not available)", "OID": ".1.3.6.1.2.1.31.1.1.1.1", "fallback_OID": ".1.3.6.1.2.1.2.2.1.2", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"}`
+
+//ifTags tags of interface metrics
+const ifTags = `[
+      {"name": "ifAlias", "OID": ".1.3.6.1.2.1.31.1.1.1.18", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"},
+      {"name": "ifType", "OID": ".1.3.6.1.2.1.2.2.1.3", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"},
+      {"name": "ifSpeed", "OID": ".1.3.6.1.2.1.2.2.1.5", "join": "index", "cache_ttl": 3600, "cache_invalidate_OID": ".1.3.6.1.2.1.2.1.0"}
+    ]`
+
+//ifMetrics interface metrics, name is the last element of namespace and definition contains parameters of metric (in setfile format)
+//except namespace and tags which are the same for all interface metrics, utilization is divided by ifHighSpeed (Mbit/s)
+//or by ifSpeed (bit/s) converted into Mbit/s if ifHighSpeed is not available
+var ifMetrics = []struct {
+	name       string
+	definition string
+}{
+	{"in_octets", `"OID": ".1.3.6.1.2.1.31.1.1.1.6", "fallback_OID": ".1.3.6.1.2.1.2.2.1.10", "unit": "B", "description": "total number of octets received on the interface (ifHCInOctets, ifInOctets if 64-bit counters are not available)"`},
+	{"out_octets", `"OID": ".1.3.6.1.2.1.31.1.1.1.10", "fallback_OID": ".1.3.6.1.2.1.2.2.1.16", "unit": "B", "description": "total number of octets transmitted out of the interface (ifHCOutOctets, ifOutOctets if 64-bit counters are not available)"`},
+	{"in_bits_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.6", "fallback_OID": ".1.3.6.1.2.1.2.2.1.10", "rate": true, "scale": 8, "unit": "bit/s", "description": "rate of bits received on the interface"`},
+	{"out_bits_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.10", "fallback_OID": ".1.3.6.1.2.1.2.2.1.16", "rate": true, "scale": 8, "unit": "bit/s", "description": "rate of bits transmitted out of the interface"`},
+	{"in_unicast_packets_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.7", "fallback_OID": ".1.3.6.1.2.1.2.2.1.11", "rate": true, "unit": "packets/s", "description": "rate of unicast packets received on the interface"`},
+	{"out_unicast_packets_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.11", "fallback_OID": ".1.3.6.1.2.1.2.2.1.17", "rate": true, "unit": "packets/s", "description": "rate of unicast packets transmitted out of the interface"`},
+	{"in_multicast_packets_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.8", "fallback_OID": ".1.3.6.1.2.1.31.1.1.1.2", "rate": true, "unit": "packets/s", "description": "rate of multicast packets received on the interface"`},
+	{"out_multicast_packets_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.12", "fallback_OID": ".1.3.6.1.2.1.31.1.1.1.4", "rate": true, "unit": "packets/s", "description": "rate of multicast packets transmitted out of the interface"`},
+	{"in_broadcast_packets_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.9", "fallback_OID": ".1.3.6.1.2.1.31.1.1.1.3", "rate": true, "unit": "packets/s", "description": "rate of broadcast packets received on the interface"`},
+	{"out_broadcast_packets_per_second", `"OID": ".1.3.6.1.2.1.31.1.1.1.13", "fallback_OID": ".1.3.6.1.2.1.31.1.1.1.5", "rate": true, "unit": "packets/s", "description": "rate of broadcast packets transmitted out of the interface"`},
+	{"in_errors_per_second", `"OID": ".1.3.6.1.2.1.2.2.1.14", "rate": true, "unit": "errors/s", "description": "rate of inbound packets which contained errors"`},
+	{"out_errors_per_second", `"OID": ".1.3.6.1.2.1.2.2.1.20", "rate": true, "unit": "errors/s", "description": "rate of outbound packets which could not be transmitted because of errors"`},
+	{"in_discards_per_second", `"OID": ".1.3.6.1.2.1.2.2.1.13", "rate": true, "unit": "packets/s", "description": "rate of inbound packets which were discarded"`},
+	{"out_discards_per_second", `"OID": ".1.3.6.1.2.1.2.2.1.19", "rate": true, "unit": "packets/s", "description": "rate of outbound packets which were discarded"`},
+	{"in_utilization_percent", `"OID": ".1.3.6.1.2.1.31.1.1.1.6", "fallback_OID": ".1.3.6.1.2.1.2.2.1.10", "rate": true, "divide_by_OID": ".1.3.6.1.2.1.31.1.1.1.15", "divide_by_fallback_OID": ".1.3.6.1.2.1.2.2.1.5", "divide_by_fallback_scale": 0.000001, "scale": 0.0008, "unit": "%", "description": "utilization of the interface in inbound direction (rate of bits divided by ifHighSpeed, ifSpeed if ifHighSpeed is not available)"`},
+	{"out_utilization_percent", `"OID": ".1.3.6.1.2.1.31.1.1.1.10", "fallback_OID": ".1.3.6.1.2.1.2.2.1.16", "rate": true, "divide_by_OID": ".1.3.6.1.2.1.31.1.1.1.15", "divide_by_fallback_OID": ".1.3.6.1.2.1.2.2.1.5", "divide_by_fallback_scale": 0.000001, "scale": 0.0008, "unit": "%", "description": "utilization of the interface in outbound direction (rate of bits divided by ifHighSpeed, ifSpeed if ifHighSpeed is not available)"`},
+	{"admin_status", `"OID": ".1.3.6.1.2.1.2.2.1.7", "unit": "", "description": "desired state of the interface (1 - up, 2 - down, 3 - testing)"`},
+	{"oper_status", `"OID": ".1.3.6.1.2.1.2.2.1.8", "unit": "", "description": "current operational state of the interface (1 - up, 2 - down, 3 - testing, 4 - unknown, 5 - dormant, 6 - notPresent, 7 - lowerLayerDown)"`},
+}
+
+//ifMib profile of interface metrics (IF-MIB, RFC 2863), 64-bit counters (ifXTable) are preferred and 32-bit counters (ifTable) are used
+//if 64-bit counters are not available
+var ifMib = ifMibProfile()
+
+//ifMibProfile builds definitions of interface metrics from namespace and tags shared by all of them
+func ifMibProfile() string {
+	definitions := []string{}
+	for _, metric := range ifMetrics {
+		definitions = append(definitions, fmt.Sprintf(`  {
+    "mode": "table",
+    "namespace": [
+      %s,
+      {"source": "string", "string": "%s"}
+    ],
+    %s,
+    "tags": %s
+  }`, ifNamespace, metric.name, metric.definition, ifTags))
+	}
+	return "[\n" + strings.Join(definitions, ",\n") + "\n]\n"
+}
@@ -0,0 +1,139 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+//lldp profile of neighbors discovered by LLDP (LLDP-MIB, IEEE 802.1AB), identifiers of chassis and ports are decoded according to
+//their subtypes and statistics of changes of neighbors allow to detect changes of topology
+const lldp = `[
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "local_port", "description": "identifier of local port (lldpLocPortId)", "OID": ".1.0.8802.1.1.2.1.3.7.1.3", "join": "index", "oid_part": 12, "subtype_OID": ".1.0.8802.1.1.2.1.3.7.1.2", "subtype_encoding": {"1": "string", "2": "string", "3": "mac", "4": "network_address", "5": "string", "6": "hex", "7": "string"}, "cache_ttl": 3600},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local port (lldpRemIndex)", "oid_part": 13},
+      {"source": "string", "string": "chassis_id"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.4.1.1.5",
+    "subtype_OID": ".1.0.8802.1.1.2.1.4.1.1.4",
+    "subtype_encoding": {"1": "string", "2": "string", "3": "string", "4": "mac", "5": "network_address", "6": "string", "7": "string"},
+    "unit": "",
+    "description": "chassis identifier of neighbor (lldpRemChassisId) decoded according to lldpRemChassisIdSubtype"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "local_port", "description": "identifier of local port (lldpLocPortId)", "OID": ".1.0.8802.1.1.2.1.3.7.1.3", "join": "index", "oid_part": 12, "subtype_OID": ".1.0.8802.1.1.2.1.3.7.1.2", "subtype_encoding": {"1": "string", "2": "string", "3": "mac", "4": "network_address", "5": "string", "6": "hex", "7": "string"}, "cache_ttl": 3600},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local port (lldpRemIndex)", "oid_part": 13},
+      {"source": "string", "string": "port_id"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.4.1.1.7",
+    "subtype_OID": ".1.0.8802.1.1.2.1.4.1.1.6",
+    "subtype_encoding": {"1": "string", "2": "string", "3": "mac", "4": "network_address", "5": "string", "6": "hex", "7": "string"},
+    "unit": "",
+    "description": "port identifier of neighbor (lldpRemPortId) decoded according to lldpRemPortIdSubtype"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "local_port", "description": "identifier of local port (lldpLocPortId)", "OID": ".1.0.8802.1.1.2.1.3.7.1.3", "join": "index", "oid_part": 12, "subtype_OID": ".1.0.8802.1.1.2.1.3.7.1.2", "subtype_encoding": {"1": "string", "2": "string", "3": "mac", "4": "network_address", "5": "string", "6": "hex", "7": "string"}, "cache_ttl": 3600},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local port (lldpRemIndex)", "oid_part": 13},
+      {"source": "string", "string": "port_description"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.4.1.1.8",
+    "unit": "",
+    "description": "description of port of neighbor (lldpRemPortDesc)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "snmp", "name": "local_port", "description": "identifier of local port (lldpLocPortId)", "OID": ".1.0.8802.1.1.2.1.3.7.1.3", "join": "index", "oid_part": 12, "subtype_OID": ".1.0.8802.1.1.2.1.3.7.1.2", "subtype_encoding": {"1": "string", "2": "string", "3": "mac", "4": "network_address", "5": "string", "6": "hex", "7": "string"}, "cache_ttl": 3600},
+      {"source": "index", "name": "neighbor", "description": "index of neighbor on local port (lldpRemIndex)", "oid_part": 13},
+      {"source": "string", "string": "system_name"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.4.1.1.9",
+    "unit": "",
+    "description": "system name of neighbor (lldpRemSysName)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "topology"},
+      {"source": "string", "string": "last_change_time"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.2.1.0",
+    "unit": "1/100 s",
+    "description": "value of sysUpTime when neighbors were inserted, deleted or changed last time (lldpStatsRemTablesLastChangeTime)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "topology"},
+      {"source": "string", "string": "inserts"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.2.2.0",
+    "unit": "",
+    "description": "number of times neighbors were inserted (lldpStatsRemTablesInserts)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "topology"},
+      {"source": "string", "string": "deletes"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.2.3.0",
+    "unit": "",
+    "description": "number of times neighbors were deleted (lldpStatsRemTablesDeletes)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "topology"},
+      {"source": "string", "string": "drops"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.2.4.0",
+    "unit": "",
+    "description": "number of times neighbors could not be inserted because of insufficient resources (lldpStatsRemTablesDrops)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "lldp"},
+      {"source": "string", "string": "topology"},
+      {"source": "string", "string": "ageouts"}
+    ],
+    "OID": ".1.0.8802.1.1.2.1.2.5.0",
+    "unit": "",
+    "description": "number of times neighbors were deleted because information timed out (lldpStatsRemTablesAgeouts)"
+  }
+]
+`
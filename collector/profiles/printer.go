@@ -0,0 +1,132 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+//supplyNamespace namespace elements of supply metrics (printer/supply/<supply>)
+const supplyNamespace = `{"source": "string", "string": "printer"},
+      {"source": "string", "string": "supply"},
+      {"source": "snmp", "name": "supply", "description": "description of supply (prtMarkerSuppliesDescription)", "OID": ".1.3.6.1.2.1.43.11.1.1.6", "join": "index", "cache_ttl": 3600}`
+
+//supplyTags tags of supply metrics, type and unit of supply are mapped from enumerations of Printer-MIB
+const supplyTags = `[
+      {"name": "prtMarkerSuppliesType", "OID": ".1.3.6.1.2.1.43.11.1.1.5", "join": "index", "cache_ttl": 3600, "value_map": {"1": "other", "2": "unknown", "3": "toner", "4": "waste_toner", "5": "ink", "6": "ink_cartridge", "7": "ink_ribbon", "8": "waste_ink", "9": "opc", "10": "developer", "11": "fuser_oil", "12": "solid_wax", "13": "ribbon_wax", "14": "waste_wax", "15": "fuser", "16": "corona_wire", "17": "fuser_oil_wick", "18": "cleaner_unit", "19": "fuser_cleaning_pad", "20": "transfer_unit", "21": "toner_cartridge", "22": "fuser_oiler", "23": "water", "24": "waste_water", "25": "glue_water_additive", "26": "waste_paper", "27": "binding_supply", "28": "banding_supply", "29": "stitching_wire", "30": "shrink_wrap", "31": "paper_wrap", "32": "staples", "33": "inserts", "34": "covers"}},
+      {"name": "prtMarkerSuppliesSupplyUnit", "OID": ".1.3.6.1.2.1.43.11.1.1.7", "join": "index", "cache_ttl": 3600, "value_map": {"1": "other", "2": "unknown", "3": "ten_thousandths_of_inches", "4": "micrometers", "7": "impressions", "8": "sheets", "11": "hours", "12": "thousandths_of_ounces", "13": "tenths_of_grams", "14": "hundreths_of_fluid_ounces", "15": "tenths_of_milliliters", "16": "feet", "17": "meters", "18": "items", "19": "percent"}}
+    ]`
+
+//supplyMetrics supply metrics, name is the last element of namespace and definition contains parameters of metric (in setfile format)
+//except namespace and tags which are the same for all supply metrics
+var supplyMetrics = []struct {
+	name       string
+	definition string
+}{
+	{"level_percent", `"OID": ".1.3.6.1.2.1.43.11.1.1.9", "divide_by_OID": ".1.3.6.1.2.1.43.11.1.1.8", "scale": 100, "skip_values": ["-1", "-2", "-3"], "unit": "%", "description": "remaining level of supply in percent (prtMarkerSuppliesLevel divided by prtMarkerSuppliesMaxCapacity), it is not returned if level or capacity is unknown or restricted"`},
+	{"level", `"OID": ".1.3.6.1.2.1.43.11.1.1.9", "unit": "", "description": "remaining level of supply in units of prtMarkerSuppliesSupplyUnit (prtMarkerSuppliesLevel: -1 - no restriction, -2 - unknown, -3 - at least one unit remains)"`},
+	{"max_capacity", `"OID": ".1.3.6.1.2.1.43.11.1.1.8", "unit": "", "description": "maximum capacity of supply in units of prtMarkerSuppliesSupplyUnit (prtMarkerSuppliesMaxCapacity: -1 - no restriction, -2 - unknown)"`},
+}
+
+//printerMetrics metrics of markers, printer devices and alerts
+const printerMetrics = `  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "printer"},
+      {"source": "string", "string": "marker"},
+      {"source": "index", "name": "device_index", "description": "index of printer device (hrDeviceIndex)", "oid_part": 11},
+      {"source": "index", "name": "marker", "description": "index of marker (prtMarkerIndex)", "oid_part": 12},
+      {"source": "string", "string": "life_count"}
+    ],
+    "OID": ".1.3.6.1.2.1.43.10.2.1.4",
+    "tags": [
+      {"name": "prtMarkerCounterUnit", "OID": ".1.3.6.1.2.1.43.10.2.1.3", "join": "index", "value_map": {"3": "ten_thousandths_of_inches", "4": "micrometers", "5": "characters", "6": "lines", "7": "impressions", "8": "sheets", "9": "dot_row", "11": "hours", "16": "feet", "17": "meters"}}
+    ],
+    "unit": "",
+    "description": "number of units (usually impressions) counted during life of printer (prtMarkerLifeCount)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "printer"},
+      {"source": "string", "string": "device"},
+      {"source": "snmp", "name": "device", "description": "description of printer device (hrDeviceDescr)", "OID": ".1.3.6.1.2.1.25.3.2.1.3", "join": "index", "oid_part": 11, "cache_ttl": 3600},
+      {"source": "string", "string": "status"}
+    ],
+    "OID": ".1.3.6.1.2.1.25.3.5.1.1",
+    "value_map": {"1": "other", "2": "unknown", "3": "idle", "4": "printing", "5": "warmup"},
+    "unit": "",
+    "description": "current status of printer device (hrPrinterStatus: other, unknown, idle, printing, warmup)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "printer"},
+      {"source": "string", "string": "device"},
+      {"source": "snmp", "name": "device", "description": "description of printer device (hrDeviceDescr)", "OID": ".1.3.6.1.2.1.25.3.2.1.3", "join": "index", "oid_part": 11, "cache_ttl": 3600},
+      {"source": "string", "string": "device_status"}
+    ],
+    "OID": ".1.3.6.1.2.1.25.3.2.1.5",
+    "value_map": {"1": "unknown", "2": "running", "3": "warning", "4": "testing", "5": "down"},
+    "unit": "",
+    "description": "current operational state of device (hrDeviceStatus: unknown, running, warning, testing, down)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "printer"},
+      {"source": "string", "string": "alert"},
+      {"source": "index", "name": "device_index", "description": "index of printer device (hrDeviceIndex)", "oid_part": 11},
+      {"source": "index", "name": "alert", "description": "index of active alert (prtAlertIndex)", "oid_part": 12},
+      {"source": "string", "string": "severity"}
+    ],
+    "OID": ".1.3.6.1.2.1.43.18.1.1.2",
+    "value_map": {"1": "other", "3": "critical", "4": "warning", "5": "warning_binary_change_event"},
+    "tags": [
+      {"name": "prtAlertDescription", "OID": ".1.3.6.1.2.1.43.18.1.1.8", "join": "index"},
+      {"name": "prtAlertCode", "OID": ".1.3.6.1.2.1.43.18.1.1.7", "join": "index"}
+    ],
+    "unit": "",
+    "description": "severity of active alert (prtAlertSeverityLevel: other, critical, warning, warning_binary_change_event)"
+  }`
+
+//printer profile of supplies, counters and alerts (Printer-MIB, RFC 3805) and status of printers (HOST-RESOURCES-MIB, RFC 2790)
+var printer = printerProfile()
+
+//printerProfile builds definitions of supply metrics from namespace and tags shared by all of them, followed by the other printer metrics
+func printerProfile() string {
+	definitions := []string{}
+	for _, metric := range supplyMetrics {
+		definitions = append(definitions, fmt.Sprintf(`  {
+    "mode": "table",
+    "namespace": [
+      %s,
+      {"source": "string", "string": "%s"}
+    ],
+    %s,
+    "tags": %s
+  }`, supplyNamespace, metric.name, metric.definition, supplyTags))
+	}
+	definitions = append(definitions, printerMetrics)
+	return "[\n" + strings.Join(definitions, ",\n") + "\n]\n"
+}
@@ -0,0 +1,59 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//Package profiles contains built-in definitions of metrics (in setfile format) for commonly used MIBs
+package profiles
+
+import (
+	"fmt"
+	"sort"
+)
+
+//profiles built-in profiles, key is name of profile which is used in configuration
+var profiles = map[string]string{
+	"cdp":     cdp,
+	"entity":  entity,
+	"host":    host,
+	"if-mib":  ifMib,
+	"lldp":    lldp,
+	"printer": printer,
+	"routing": routing,
+	"ups":     ups,
+}
+
+//Get returns definition of metrics (content of setfile) for profile
+func Get(name string) ([]byte, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown profile (%s), available profiles: %v", name, Names())
+	}
+	return []byte(profile), nil
+}
+
+//Names returns sorted names of built-in profiles
+func Names() []string {
+	names := []string{}
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
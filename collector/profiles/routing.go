@@ -0,0 +1,172 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+//routing profile of BGP peers (BGP4-MIB, RFC 4273) and OSPF neighbors (OSPF-MIB, RFC 4750), states are returned as labels
+//and transitions of states are returned as separate metrics
+const routing = `[
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "bgp"},
+      {"source": "string", "string": "peer"},
+      {"source": "index", "name": "peer", "description": "remote IP address of BGP peer (bgpPeerRemoteAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "string", "string": "state"}
+    ],
+    "OID": ".1.3.6.1.2.1.15.3.1.2",
+    "value_map": {"1": "idle", "2": "connect", "3": "active", "4": "opensent", "5": "openconfirm", "6": "established"},
+    "tags": [
+      {"name": "bgpPeerRemoteAs", "OID": ".1.3.6.1.2.1.15.3.1.9", "join": "index"}
+    ],
+    "unit": "",
+    "description": "state of BGP connection (bgpPeerState: idle, connect, active, opensent, openconfirm, established)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "bgp"},
+      {"source": "string", "string": "peer"},
+      {"source": "index", "name": "peer", "description": "remote IP address of BGP peer (bgpPeerRemoteAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "string", "string": "state_change"}
+    ],
+    "OID": ".1.3.6.1.2.1.15.3.1.2",
+    "value_map": {"1": "idle", "2": "connect", "3": "active", "4": "opensent", "5": "openconfirm", "6": "established"},
+    "change": true,
+    "tags": [
+      {"name": "bgpPeerRemoteAs", "OID": ".1.3.6.1.2.1.15.3.1.9", "join": "index"}
+    ],
+    "unit": "",
+    "description": "state of BGP connection returned only if it differs from state in previous collection, previous state is added as tag"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "bgp"},
+      {"source": "string", "string": "peer"},
+      {"source": "index", "name": "peer", "description": "remote IP address of BGP peer (bgpPeerRemoteAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "string", "string": "established_time"}
+    ],
+    "OID": ".1.3.6.1.2.1.15.3.1.16",
+    "tags": [
+      {"name": "bgpPeerRemoteAs", "OID": ".1.3.6.1.2.1.15.3.1.9", "join": "index"}
+    ],
+    "unit": "s",
+    "description": "time since BGP peer was last in established state or since it was last up (bgpPeerFsmEstablishedTime)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "bgp"},
+      {"source": "string", "string": "peer"},
+      {"source": "index", "name": "peer", "description": "remote IP address of BGP peer (bgpPeerRemoteAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "string", "string": "established_transitions"}
+    ],
+    "OID": ".1.3.6.1.2.1.15.3.1.15",
+    "tags": [
+      {"name": "bgpPeerRemoteAs", "OID": ".1.3.6.1.2.1.15.3.1.9", "join": "index"}
+    ],
+    "unit": "",
+    "description": "number of times BGP connection has transitioned into established state (bgpPeerFsmEstablishedTransitions)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "bgp"},
+      {"source": "string", "string": "peer"},
+      {"source": "index", "name": "peer", "description": "remote IP address of BGP peer (bgpPeerRemoteAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "string", "string": "in_updates"}
+    ],
+    "OID": ".1.3.6.1.2.1.15.3.1.10",
+    "tags": [
+      {"name": "bgpPeerRemoteAs", "OID": ".1.3.6.1.2.1.15.3.1.9", "join": "index"}
+    ],
+    "unit": "",
+    "description": "number of BGP UPDATE messages received on connection (bgpPeerInUpdates)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "bgp"},
+      {"source": "string", "string": "peer"},
+      {"source": "index", "name": "peer", "description": "remote IP address of BGP peer (bgpPeerRemoteAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "string", "string": "out_updates"}
+    ],
+    "OID": ".1.3.6.1.2.1.15.3.1.11",
+    "tags": [
+      {"name": "bgpPeerRemoteAs", "OID": ".1.3.6.1.2.1.15.3.1.9", "join": "index"}
+    ],
+    "unit": "",
+    "description": "number of BGP UPDATE messages transmitted on connection (bgpPeerOutUpdates)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ospf"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "index", "name": "neighbor", "description": "IP address of OSPF neighbor (ospfNbrIpAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "index", "name": "address_less_index", "description": "interface index of unnumbered OSPF neighbor, 0 for neighbor with IP address (ospfNbrAddressLessIndex)", "oid_part": 14},
+      {"source": "string", "string": "state"}
+    ],
+    "OID": ".1.3.6.1.2.1.14.10.1.6",
+    "value_map": {"1": "down", "2": "attempt", "3": "init", "4": "two_way", "5": "exchange_start", "6": "exchange", "7": "loading", "8": "full"},
+    "tags": [
+      {"name": "ospfNbrRtrId", "OID": ".1.3.6.1.2.1.14.10.1.3", "join": "index"}
+    ],
+    "unit": "",
+    "description": "state of relationship with OSPF neighbor (ospfNbrState: down, attempt, init, two_way, exchange_start, exchange, loading, full)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ospf"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "index", "name": "neighbor", "description": "IP address of OSPF neighbor (ospfNbrIpAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "index", "name": "address_less_index", "description": "interface index of unnumbered OSPF neighbor, 0 for neighbor with IP address (ospfNbrAddressLessIndex)", "oid_part": 14},
+      {"source": "string", "string": "state_change"}
+    ],
+    "OID": ".1.3.6.1.2.1.14.10.1.6",
+    "value_map": {"1": "down", "2": "attempt", "3": "init", "4": "two_way", "5": "exchange_start", "6": "exchange", "7": "loading", "8": "full"},
+    "change": true,
+    "tags": [
+      {"name": "ospfNbrRtrId", "OID": ".1.3.6.1.2.1.14.10.1.3", "join": "index"}
+    ],
+    "unit": "",
+    "description": "state of relationship with OSPF neighbor returned only if it differs from state in previous collection, previous state is added as tag"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ospf"},
+      {"source": "string", "string": "neighbor"},
+      {"source": "index", "name": "neighbor", "description": "IP address of OSPF neighbor (ospfNbrIpAddr)", "oid_part": 10, "encoding": "ipv4"},
+      {"source": "index", "name": "address_less_index", "description": "interface index of unnumbered OSPF neighbor, 0 for neighbor with IP address (ospfNbrAddressLessIndex)", "oid_part": 14},
+      {"source": "string", "string": "events"}
+    ],
+    "OID": ".1.3.6.1.2.1.14.10.1.7",
+    "tags": [
+      {"name": "ospfNbrRtrId", "OID": ".1.3.6.1.2.1.14.10.1.3", "join": "index"}
+    ],
+    "unit": "",
+    "description": "number of times state of relationship with OSPF neighbor has changed or error has occurred (ospfNbrEvents)"
+  }
+]
+`
@@ -0,0 +1,257 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+//ups profile of UPS devices and PDUs which implement UPS-MIB (RFC 1628), enumerations are returned as labels
+//and active alarms are returned as separate metrics named by alarm descriptor
+const ups = `[
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "status"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.1.0",
+    "value_map": {"1": "unknown", "2": "battery_normal", "3": "battery_low", "4": "battery_depleted"},
+    "unit": "",
+    "description": "status of battery (upsBatteryStatus: unknown, battery_normal, battery_low, battery_depleted)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "seconds_on_battery"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.2.0",
+    "unit": "s",
+    "description": "time since UPS switched to battery power, 0 if UPS is not on battery power (upsSecondsOnBattery)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "minutes_remaining"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.3.0",
+    "unit": "min",
+    "description": "estimated time to battery charge depletion (upsEstimatedMinutesRemaining)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "charge_remaining_percent"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.4.0",
+    "unit": "%",
+    "description": "estimated battery charge remaining (upsEstimatedChargeRemaining)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "voltage"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.5.0",
+    "scale": 0.1,
+    "unit": "V",
+    "description": "battery voltage (upsBatteryVoltage)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "current"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.6.0",
+    "scale": 0.1,
+    "unit": "A",
+    "description": "battery current (upsBatteryCurrent)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "battery"},
+      {"source": "string", "string": "temperature"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.2.7.0",
+    "unit": "C",
+    "description": "temperature at or near the battery (upsBatteryTemperature)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "input"},
+      {"source": "index", "name": "line", "description": "index of input line (upsInputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "frequency"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.3.3.1.2",
+    "scale": 0.1,
+    "unit": "Hz",
+    "description": "input frequency (upsInputFrequency)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "input"},
+      {"source": "index", "name": "line", "description": "index of input line (upsInputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "voltage"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.3.3.1.3",
+    "unit": "V",
+    "description": "input voltage (upsInputVoltage)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "input"},
+      {"source": "index", "name": "line", "description": "index of input line (upsInputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "current"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.3.3.1.4",
+    "scale": 0.1,
+    "unit": "A",
+    "description": "input current (upsInputCurrent)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "input"},
+      {"source": "index", "name": "line", "description": "index of input line (upsInputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "true_power"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.3.3.1.5",
+    "unit": "W",
+    "description": "input true power (upsInputTruePower)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "output"},
+      {"source": "string", "string": "source"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.4.1.0",
+    "value_map": {"1": "other", "2": "none", "3": "normal", "4": "bypass", "5": "battery", "6": "booster", "7": "reducer"},
+    "unit": "",
+    "description": "present source of output power (upsOutputSource: other, none, normal, bypass, battery, booster, reducer)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "output"},
+      {"source": "string", "string": "frequency"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.4.2.0",
+    "scale": 0.1,
+    "unit": "Hz",
+    "description": "output frequency (upsOutputFrequency)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "output"},
+      {"source": "index", "name": "line", "description": "index of output line (upsOutputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "voltage"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.4.4.1.2",
+    "unit": "V",
+    "description": "output voltage (upsOutputVoltage)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "output"},
+      {"source": "index", "name": "line", "description": "index of output line (upsOutputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "current"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.4.4.1.3",
+    "scale": 0.1,
+    "unit": "A",
+    "description": "output current (upsOutputCurrent)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "output"},
+      {"source": "index", "name": "line", "description": "index of output line (upsOutputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "power"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.4.4.1.4",
+    "unit": "W",
+    "description": "output true power (upsOutputPower)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "output"},
+      {"source": "index", "name": "line", "description": "index of output line (upsOutputLineIndex)", "oid_part": 12},
+      {"source": "string", "string": "load_percent"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.4.4.1.5",
+    "unit": "%",
+    "description": "percentage of UPS power capacity presently being used (upsOutputPercentLoad)"
+  },
+  {
+    "mode": "single",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "alarms"},
+      {"source": "string", "string": "present"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.6.1.0",
+    "unit": "",
+    "description": "number of active alarms (upsAlarmsPresent)"
+  },
+  {
+    "mode": "table",
+    "namespace": [
+      {"source": "string", "string": "ups"},
+      {"source": "string", "string": "alarm"},
+      {"source": "snmp", "name": "alarm", "description": "descriptor of active alarm (upsAlarmDescr), well-known alarms are named and other alarms are identified by OID", "OID": ".1.3.6.1.2.1.33.1.6.2.1.2", "join": "index", "value_map": {".1.3.6.1.2.1.33.1.6.3.1": "battery_bad", ".1.3.6.1.2.1.33.1.6.3.2": "on_battery", ".1.3.6.1.2.1.33.1.6.3.3": "low_battery", ".1.3.6.1.2.1.33.1.6.3.4": "depleted_battery", ".1.3.6.1.2.1.33.1.6.3.5": "temp_bad", ".1.3.6.1.2.1.33.1.6.3.6": "input_bad", ".1.3.6.1.2.1.33.1.6.3.7": "output_bad", ".1.3.6.1.2.1.33.1.6.3.8": "output_overload", ".1.3.6.1.2.1.33.1.6.3.9": "on_bypass", ".1.3.6.1.2.1.33.1.6.3.10": "bypass_bad", ".1.3.6.1.2.1.33.1.6.3.11": "output_off_as_requested", ".1.3.6.1.2.1.33.1.6.3.12": "ups_off_as_requested", ".1.3.6.1.2.1.33.1.6.3.13": "charger_failed", ".1.3.6.1.2.1.33.1.6.3.14": "ups_output_off", ".1.3.6.1.2.1.33.1.6.3.15": "ups_system_off", ".1.3.6.1.2.1.33.1.6.3.16": "fan_failure", ".1.3.6.1.2.1.33.1.6.3.17": "fuse_failure", ".1.3.6.1.2.1.33.1.6.3.18": "general_fault", ".1.3.6.1.2.1.33.1.6.3.19": "diagnostic_test_failed", ".1.3.6.1.2.1.33.1.6.3.20": "communications_lost", ".1.3.6.1.2.1.33.1.6.3.21": "awaiting_power", ".1.3.6.1.2.1.33.1.6.3.22": "shutdown_pending", ".1.3.6.1.2.1.33.1.6.3.23": "shutdown_imminent", ".1.3.6.1.2.1.33.1.6.3.24": "test_in_progress"}},
+      {"source": "string", "string": "time"}
+    ],
+    "OID": ".1.3.6.1.2.1.33.1.6.2.1.3",
+    "tags": [
+      {"name": "upsAlarmDescr", "OID": ".1.3.6.1.2.1.33.1.6.2.1.2", "join": "index"}
+    ],
+    "unit": "1/100 s",
+    "description": "value of sysUpTime when alarm was detected (upsAlarmTime), metric is returned for each of active alarms"
+  }
+]
+`
@@ -0,0 +1,137 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	log "github.com/sirupsen/logrus"
+)
+
+type resolvedAddress struct {
+	address string
+	expires time.Time
+}
+
+var (
+	//lookupIP resolves host name, it can be replaced in tests
+	lookupIP = net.LookupIP
+
+	//resolvedAddresses cache of resolved SNMP agent addresses, key is built from network and normalized SNMP agent address
+	resolvedAddresses    = make(map[string]resolvedAddress)
+	mtxResolvedAddresses = &sync.Mutex{}
+)
+
+//addressKey returns key of SNMP agent address, it is built from network and normalized SNMP agent address
+func addressKey(agentConfig configReader.SnmpAgent) string {
+	return agentConfig.Network + "://" + agentConfig.Address
+}
+
+//connectionKey returns key of SNMP connection, it is built from key of SNMP agent address and fingerprint of credentials,
+//so configurations with different credentials for the same SNMP agent use separate connections
+func connectionKey(agentConfig configReader.SnmpAgent) string {
+	return addressKey(agentConfig) + "#" + credentialsFingerprint(agentConfig)
+}
+
+//credentialsFingerprint returns hash of parameters of SNMP session, credentials are not kept in keys of connections
+func credentialsFingerprint(agentConfig configReader.SnmpAgent) string {
+	sessions := [][]interface{}{}
+	for _, c := range append([]configReader.SnmpAgent{agentConfig}, agentConfig.CredentialSets...) {
+		sessions = append(sessions, []interface{}{c.CredentialSet, c.SnmpVersion, c.Community, c.UserName, c.SecurityLevel,
+			c.AuthPassword, c.AuthProtocol, c.PrivPassword, c.PrivProtocol, c.SecurityEngineId, c.ContextEngineId, c.ContextName,
+			c.Retries, c.Timeout, c.CaptureMode, c.CaptureFile})
+	}
+
+	encoded, _ := json.Marshal(sessions)
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:8])
+}
+
+//resolveAgentAddress returns SNMP agent address with host name replaced by IP address,
+//resolved addresses are cached and host name is resolved again when DNS refresh interval elapses
+func resolveAgentAddress(agentConfig configReader.SnmpAgent) (string, error) {
+	host, port, err := net.SplitHostPort(agentConfig.Address)
+	if err != nil {
+		return "", err
+	}
+
+	//IP address does not need to be resolved, host name is not resolved in replay of capture which can be used offline
+	if net.ParseIP(strings.Split(host, "%")[0]) != nil || agentConfig.CaptureMode == configReader.CaptureModeReplay {
+		return agentConfig.Address, nil
+	}
+
+	key := addressKey(agentConfig)
+
+	mtxResolvedAddresses.Lock()
+	cached, ok := resolvedAddresses[key]
+	mtxResolvedAddresses.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.address, nil
+	}
+
+	//cache is not locked during lookup, so slow DNS does not block resolving of other SNMP agents
+	ip, err := lookupHost(host, agentConfig.Network)
+	if err != nil {
+		if ok {
+			//keep using the last resolved address if DNS is not available
+			log.WithFields(log.Fields{"agent_address": agentConfig.Address, "resolved_address": cached.address}).Warn(err)
+			return cached.address, nil
+		}
+		return "", err
+	}
+
+	address := net.JoinHostPort(ip.String(), port)
+	mtxResolvedAddresses.Lock()
+	resolvedAddresses[key] = resolvedAddress{
+		address: address,
+		expires: time.Now().Add(time.Duration(agentConfig.DNSRefreshInterval) * time.Second),
+	}
+	mtxResolvedAddresses.Unlock()
+	return address, nil
+}
+
+//lookupHost resolves host name and returns the first IP address which can be used with network
+func lookupHost(host string, network string) (net.IP, error) {
+	ips, err := lookupIP(host)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot resolve SNMP agent host name (%s): %v", host, err)
+	}
+
+	for _, ip := range ips {
+		switch {
+		case strings.HasSuffix(network, "4") && ip.To4() == nil:
+			continue
+		case strings.HasSuffix(network, "6") && ip.To4() != nil:
+			continue
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("Cannot resolve SNMP agent host name (%s), no IP address for network (%s)", host, network)
+}
@@ -0,0 +1,194 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snmp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/k-sone/snmpgo"
+)
+
+const (
+	//CategoryTimeout category of errors when SNMP agent does not respond in time
+	CategoryTimeout = "timeout"
+
+	//CategoryAuth category of errors when SNMPv3 agent rejects request with report (e.g. unknown user name or wrong digest)
+	CategoryAuth = "auth"
+
+	//CategoryAgent category of errors when SNMP agent responds with error status
+	CategoryAgent = "agent"
+
+	//CategoryTooBig category of errors when SNMP agent responds with tooBig error status
+	CategoryTooBig = "too_big"
+
+	//CategoryMalformed category of errors when response of SNMP agent cannot be accepted
+	CategoryMalformed = "malformed"
+
+	//CategoryTransport category of errors when request cannot be sent to SNMP agent (e.g. connection refused)
+	CategoryTransport = "transport"
+
+	//CategoryOther category of errors which are not returned by SNMP exchange (e.g. incorrect OID)
+	CategoryOther = "other"
+)
+
+//Categories categories of errors returned by ReadElements
+var Categories = []string{CategoryTimeout, CategoryAuth, CategoryAgent, CategoryTooBig, CategoryMalformed, CategoryTransport, CategoryOther}
+
+//usmReports names of User-based Security Model statistics (RFC 3414) which are sent by SNMPv3 agent in report PDU, by OID
+var usmReports = map[string]string{
+	"1.3.6.1.6.3.15.1.1.1": "usmStatsUnsupportedSecLevels",
+	"1.3.6.1.6.3.15.1.1.2": "usmStatsNotInTimeWindows",
+	"1.3.6.1.6.3.15.1.1.3": "usmStatsUnknownUserNames",
+	"1.3.6.1.6.3.15.1.1.4": "usmStatsUnknownEngineIDs",
+	"1.3.6.1.6.3.15.1.1.5": "usmStatsWrongDigests",
+	"1.3.6.1.6.3.15.1.1.6": "usmStatsDecryptionErrors",
+}
+
+//TimeoutError is returned when SNMP agent does not respond before timeout, also after retries
+type TimeoutError struct {
+	Cause error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("SNMP agent does not respond: %v", e.Cause)
+}
+
+//AuthError is returned when SNMPv3 agent rejects request with report PDU, report is name of usmStats counter
+//(e.g. usmStatsUnknownUserNames or usmStatsWrongDigests)
+type AuthError struct {
+	Report string
+	Cause  error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("SNMP agent rejected request (%s): %v", e.Report, e.Cause)
+}
+
+//TooBigError is returned when SNMP agent responds with tooBig error status,
+//it means that response does not fit into single message
+type TooBigError struct {
+	Index int
+}
+
+func (e *TooBigError) Error() string {
+	return fmt.Sprintf("Received an error from the SNMP agent: %v, response is too big", snmpgo.TooBig)
+}
+
+//MalformedResponseError is returned when response of SNMP agent cannot be decoded or it is not the expected one
+type MalformedResponseError struct {
+	Message string
+	Cause   error
+}
+
+func (e *MalformedResponseError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("Malformed response from the SNMP agent: %s", e.Message)
+	}
+	return fmt.Sprintf("Malformed response from the SNMP agent: %s: %v", e.Message, e.Cause)
+}
+
+//TransportError is returned when request cannot be sent to SNMP agent or response cannot be received
+type TransportError struct {
+	Cause error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("Failed to exchange messages with the SNMP agent: %v", e.Cause)
+}
+
+//Category returns category of error returned by ReadElements
+func Category(err error) string {
+	switch err.(type) {
+	case *TimeoutError:
+		return CategoryTimeout
+	case *AuthError:
+		return CategoryAuth
+	case *AgentError:
+		return CategoryAgent
+	case *TooBigError:
+		return CategoryTooBig
+	case *MalformedResponseError:
+		return CategoryMalformed
+	case *TransportError:
+		return CategoryTransport
+	}
+	return CategoryOther
+}
+
+//Responded checks if SNMP agent responded to request with error status,
+//it means that request reached SNMP agent and was authenticated
+func Responded(err error) bool {
+	switch err.(type) {
+	case *AgentError, *TooBigError:
+		return true
+	}
+	return false
+}
+
+//classify converts error of snmpgo request to error of the category
+func classify(err error) error {
+	if isTimeout(err) {
+		return &TimeoutError{Cause: err}
+	}
+
+	switch e := err.(type) {
+	case *snmpgo.ResponseError:
+		if report := usmReport(e.Message + " " + e.Detail); report != "" {
+			return &AuthError{Report: report, Cause: err}
+		}
+		return &MalformedResponseError{Message: e.Message, Cause: e.Cause}
+	case *snmpgo.MessageError:
+		return &MalformedResponseError{Message: e.Message, Cause: e.Cause}
+	}
+	return &TransportError{Cause: err}
+}
+
+//isTimeout checks if error or any of its causes is network timeout
+func isTimeout(err error) bool {
+	for err != nil {
+		switch e := err.(type) {
+		case net.Error:
+			return e.Timeout()
+		case *snmpgo.RequestError:
+			err = e.Cause
+		case *snmpgo.MessageError:
+			err = e.Cause
+		case *snmpgo.ResponseError:
+			err = e.Cause
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+//usmReport returns name of usmStats counter which is reported in message of snmpgo error, by name or by OID
+func usmReport(message string) string {
+	for oid, report := range usmReports {
+		if strings.Contains(message, report) || strings.Contains(message, oid) {
+			return report
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/k-sone/snmpgo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+//netError network error returned by connection with SNMP agent
+type netError struct {
+	timeout bool
+}
+
+func (e *netError) Error() string   { return "read udp 127.0.0.1:161: i/o timeout" }
+func (e *netError) Timeout() bool   { return e.timeout }
+func (e *netError) Temporary() bool { return e.timeout }
+
+func TestClassify(t *testing.T) {
+	Convey("Classifying errors of snmpgo", t, func() {
+		timeout := &netError{timeout: true}
+		refused := &netError{timeout: false}
+
+		tests := []struct {
+			name     string
+			err      error
+			category string
+		}{
+			{"timeout of connection", timeout, CategoryTimeout},
+			{"timeout of request", &snmpgo.RequestError{Cause: timeout, Message: "Failed to receive response"}, CategoryTimeout},
+			{"timeout in message", &snmpgo.MessageError{Cause: timeout, Message: "Failed to read message"}, CategoryTimeout},
+			{"timeout in response", &snmpgo.ResponseError{Cause: timeout, Message: "Failed to read response"}, CategoryTimeout},
+			{"nested timeout", &snmpgo.RequestError{Cause: &snmpgo.MessageError{Cause: timeout}}, CategoryTimeout},
+			{"network error other than timeout", &snmpgo.RequestError{Cause: refused, Message: "Failed to send request"}, CategoryTransport},
+			{"request error without cause", &snmpgo.RequestError{Message: "Failed to send request"}, CategoryTransport},
+			{"argument error", &snmpgo.ArgumentError{Value: 0, Message: "Unknown version"}, CategoryTransport},
+			{"message which cannot be decoded", &snmpgo.MessageError{Cause: errors.New("asn1: syntax error"), Message: "Failed to Unmarshal message"}, CategoryMalformed},
+			{"response which is not expected", &snmpgo.ResponseError{Message: "Mismatch of request id", Detail: "Request Id: 1, Response Id: 2"}, CategoryMalformed},
+		}
+		for _, test := range tests {
+			Convey("when error is "+test.name, func() {
+				err := classify(test.err)
+				So(Category(err), ShouldEqual, test.category)
+				So(Responded(err), ShouldBeFalse)
+			})
+		}
+
+		Convey("when malformed response is classified", func() {
+			cause := errors.New("asn1: syntax error")
+			err := classify(&snmpgo.MessageError{Cause: cause, Message: "Failed to Unmarshal message"})
+			So(err, ShouldResemble, &MalformedResponseError{Message: "Failed to Unmarshal message", Cause: cause})
+		})
+	})
+
+	Convey("Classifying report PDUs of SNMPv3 agent", t, func() {
+		oids := []string{}
+		for oid := range usmReports {
+			oids = append(oids, oid)
+		}
+		sort.Strings(oids)
+
+		for _, oid := range oids {
+			report := usmReports[oid]
+			Convey(fmt.Sprintf("when report is %s", report), func() {
+				Convey("reported by OID", func() {
+					responseErr := &snmpgo.ResponseError{Message: "Received a report from the agent",
+						Detail: fmt.Sprintf("Pdu: {\"Type\": \"Report\", \"VarBinds\": [{\"Oid\": \"%s.0\", \"Variable\": {\"Type\": \"Counter32\", \"Value\": \"1\"}}]}", oid)}
+					err := classify(responseErr)
+					So(err, ShouldResemble, &AuthError{Report: report, Cause: responseErr})
+					So(Category(err), ShouldEqual, CategoryAuth)
+				})
+
+				Convey("reported by name", func() {
+					responseErr := &snmpgo.ResponseError{Message: "Received a report from the agent - " + report}
+					err := classify(responseErr)
+					So(err, ShouldResemble, &AuthError{Report: report, Cause: responseErr})
+				})
+			})
+		}
+
+		Convey("when report is not USM statistic", func() {
+			So(usmReport("Received a report from the agent - snmpUnknownContexts(1.3.6.1.6.3.12.1.5.0)"), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Categories of errors of SNMP layer", t, func() {
+		tests := []struct {
+			err       error
+			category  string
+			responded bool
+		}{
+			{&TimeoutError{Cause: errors.New("i/o timeout")}, CategoryTimeout, false},
+			{&AuthError{Report: "usmStatsWrongDigests"}, CategoryAuth, false},
+			{&AgentError{Status: snmpgo.NoSuchName, Index: 1}, CategoryAgent, true},
+			{&TooBigError{Index: 1}, CategoryTooBig, true},
+			{&MalformedResponseError{Message: "Unaccepted number of results, received 0 results"}, CategoryMalformed, false},
+			{&TransportError{Cause: errors.New("connection refused")}, CategoryTransport, false},
+			{errors.New("Invalid Oid string"), CategoryOther, false},
+		}
+		for _, test := range tests {
+			So(Category(test.err), ShouldEqual, test.category)
+			So(Responded(test.err), ShouldEqual, test.responded)
+			So(Categories, ShouldContain, test.category)
+		}
+	})
+}
@@ -30,6 +30,21 @@ import (
 	"github.com/k-sone/snmpgo"
 )
 
+//AgentError is returned when SNMP agent responds with error status,
+//it means that request reached SNMP agent and was authenticated
+type AgentError struct {
+	Status snmpgo.ErrorStatus
+	Index  int
+}
+
+func (e *AgentError) Error() string {
+	return fmt.Sprintf("Received an error from the SNMP agent: %v", e.Status)
+}
+
+//ModeNext mode of reading which sends single GETNEXT request and returns the first object which follows OID,
+//it is used to check if SNMP agent has any object in subtree of OID
+const ModeNext = "next"
+
 func NewHandler(agentConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
 	handler, err := snmpgo.NewSNMP(snmpgo.SNMPArguments{
 		Version:          getSNMPVersion(agentConfig.SnmpVersion),
@@ -55,16 +70,49 @@ func NewHandler(agentConfig configReader.SnmpAgent) (*snmpgo.SNMP, error) {
 	return handler, nil
 }
 
-func ReadElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+//Sender sends single request (GET or GETNEXT) for OID to SNMP agent and returns response PDU,
+//it allows to record, replay or count requests which are sent by Walk
+type Sender func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error)
 
-	//results received through SNMP requests
-	results := []*snmpgo.VarBind{}
+//ReadElements reads OID from SNMP agent, it sends single GET request in single mode and sequence of GETNEXT requests in walk and table modes
+func ReadElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarBind, error) {
+	return Walk(func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+		return Request(handler, pduType, oid)
+	}, oid, mode)
+}
 
+//Request sends single request for OID to SNMP agent, error status of response PDU is not checked
+func Request(handler *snmpgo.SNMP, pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
 	if err := handler.Open(); err != nil {
 		// Failed to open connection
-		return results, err
+		return nil, &TransportError{Cause: err}
+	}
+
+	oids, err := snmpgo.NewOids([]string{oid})
+	if err != nil {
+		// Failed to parse Oids
+		return nil, err
 	}
 
+	var pdu snmpgo.Pdu
+	if pduType == snmpgo.GetRequest {
+		pdu, err = handler.GetRequest(oids)
+	} else {
+		pdu, err = handler.GetNextRequest(oids)
+	}
+	if err != nil {
+		// Failed to request
+		return nil, classify(err)
+	}
+	return pdu, nil
+}
+
+//Walk reads OID using requests sent by sender, response PDUs are checked and variable bindings of subtree of OID are returned
+func Walk(send Sender, oid string, mode string) ([]*snmpgo.VarBind, error) {
+
+	//results received through SNMP requests
+	results := []*snmpgo.VarBind{}
+
 	//get elements in node OID
 	nodeOid := strings.Trim(oid, ".")
 	oidParts := strings.Split(nodeOid, ".")
@@ -77,36 +125,33 @@ func ReadElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarB
 
 	//loop through one node of MIB
 	for {
-		oids, err := snmpgo.NewOids([]string{oid})
-		if err != nil {
-			// Failed to parse Oids
-			return results, err
-		}
-
-		var pdu snmpgo.Pdu
+		pduType := snmpgo.GetNextRequest
 		if mode == configReader.ModeSingle {
-			pdu, err = handler.GetRequest(oids)
-		} else {
-			pdu, err = handler.GetNextRequest(oids)
+			pduType = snmpgo.GetRequest
 		}
+
+		pdu, err := send(pduType, oid)
 		if err != nil {
-			// Failed to request
 			return results, err
 		}
 
+		if pdu.ErrorStatus() == snmpgo.TooBig {
+			return results, &TooBigError{Index: pdu.ErrorIndex()}
+		}
+
 		if pdu.ErrorStatus() != snmpgo.NoError {
 			// Received an error from the agent
-			return results, fmt.Errorf("Received an error from the SNMP agent: %v", pdu.ErrorStatus())
+			return results, &AgentError{Status: pdu.ErrorStatus(), Index: pdu.ErrorIndex()}
 		}
 
 		if len(pdu.VarBinds()) != 1 {
-			return results, fmt.Errorf("Unaccepted number of results, received %v results", len(pdu.VarBinds()))
+			return results, &MalformedResponseError{Message: fmt.Sprintf("Unaccepted number of results, received %v results", len(pdu.VarBinds()))}
 		}
 
 		// select a VarBind
 		result := pdu.VarBinds()[0]
 
-		if mode == configReader.ModeSingle {
+		if mode == configReader.ModeSingle || mode == ModeNext {
 			results = append(results, result)
 			break
 		} else {
@@ -122,9 +167,9 @@ func ReadElements(handler *snmpgo.SNMP, oid string, mode string) ([]*snmpgo.VarB
 
 			currNodeOid := strings.Join(currOidParts[:nodeOIDLength], ".")
 
-			//check if there is a new element to read
-			if nodeOid != currNodeOid || prevOid == oid ||
-				(mode == configReader.ModeTable && (len(oidParts)+1) != len(currOidParts)) {
+			//check if there is a new element to read, in table mode rows can have composite index
+			//(e.g. ifIndex and IP address), so all rows under OID of column are read
+			if nodeOid != currNodeOid || prevOid == oid {
 				break
 			}
 			prevOid = oid
@@ -159,6 +204,7 @@ func getSNMPSecurityLevel(s string) snmpgo.SecurityLevel {
 	}
 	return securitylevel
 }
+
 func getSNMPAuthProtocol(s string) snmpgo.AuthProtocol {
 	var authProtocol snmpgo.AuthProtocol
 	switch s {
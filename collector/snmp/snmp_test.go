@@ -0,0 +1,130 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snmp
+
+import (
+	"testing"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/k-sone/snmpgo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+//agentSender returns sender which serves requests from OIDs of SNMP agent, GETNEXT returns the first OID which follows requested one
+func agentSender(agent map[string]snmpgo.Variable, requests *int) Sender {
+	return func(pduType snmpgo.PduType, oid string) (snmpgo.Pdu, error) {
+		*requests++
+		requested, err := snmpgo.NewOid(oid)
+		if err != nil {
+			return nil, err
+		}
+		pdu := snmpgo.NewPdu(snmpgo.V2c, snmpgo.GetResponse)
+		if pduType == snmpgo.GetRequest {
+			if value, ok := agent[oid]; ok {
+				pdu.AppendVarBind(requested, value)
+			} else {
+				pdu.AppendVarBind(requested, snmpgo.NewNoSucheObject())
+			}
+			return pdu, nil
+		}
+
+		var next *snmpgo.Oid
+		for key := range agent {
+			candidate, _ := snmpgo.NewOid(key)
+			if candidate.Compare(requested) > 0 && (next == nil || candidate.Compare(next) < 0) {
+				next = candidate
+			}
+		}
+		if next == nil {
+			pdu.AppendVarBind(requested, snmpgo.NewEndOfMibView())
+			return pdu, nil
+		}
+		pdu.AppendVarBind(next, agent["."+next.String()])
+		return pdu, nil
+	}
+}
+
+func oids(results []*snmpgo.VarBind) []string {
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.Oid.String())
+	}
+	return names
+}
+
+func TestWalk(t *testing.T) {
+	Convey("Walking OIDs of SNMP agent", t, func() {
+		agent := map[string]snmpgo.Variable{
+			".1.3.6.1.2.1.1.5.0": snmpgo.NewOctetString([]byte("router1")),
+			//ifDescr
+			".1.3.6.1.2.1.2.2.1.2.1": snmpgo.NewOctetString([]byte("lo")),
+			".1.3.6.1.2.1.2.2.1.2.2": snmpgo.NewOctetString([]byte("eth0")),
+			//ipNetToMediaPhysAddress indexed by ifIndex and IP address
+			".1.3.6.1.2.1.4.22.1.2.2.10.0.0.1":  snmpgo.NewOctetString([]byte{0, 1, 2, 3, 4, 5}),
+			".1.3.6.1.2.1.4.22.1.2.2.10.0.0.20": snmpgo.NewOctetString([]byte{0, 1, 2, 3, 4, 6}),
+			//ipNetToMediaType
+			".1.3.6.1.2.1.4.22.1.4.2.10.0.0.1": snmpgo.NewInteger(3),
+		}
+		requests := 0
+
+		Convey("in single mode", func() {
+			results, err := Walk(agentSender(agent, &requests), ".1.3.6.1.2.1.1.5.0", configReader.ModeSingle)
+			So(err, ShouldBeNil)
+			So(oids(results), ShouldResemble, []string{"1.3.6.1.2.1.1.5.0"})
+			So(requests, ShouldEqual, 1)
+		})
+
+		Convey("in table mode with single index", func() {
+			results, err := Walk(agentSender(agent, &requests), ".1.3.6.1.2.1.2.2.1.2", configReader.ModeTable)
+			So(err, ShouldBeNil)
+			So(oids(results), ShouldResemble, []string{"1.3.6.1.2.1.2.2.1.2.1", "1.3.6.1.2.1.2.2.1.2.2"})
+		})
+
+		Convey("in table mode with composite index", func() {
+			results, err := Walk(agentSender(agent, &requests), ".1.3.6.1.2.1.4.22.1.2", configReader.ModeTable)
+			So(err, ShouldBeNil)
+			So(oids(results), ShouldResemble, []string{"1.3.6.1.2.1.4.22.1.2.2.10.0.0.1", "1.3.6.1.2.1.4.22.1.2.2.10.0.0.20"})
+
+			//GETNEXT request for each row and the last one which leaves column
+			So(requests, ShouldEqual, 3)
+		})
+
+		Convey("in walk mode", func() {
+			results, err := Walk(agentSender(agent, &requests), ".1.3.6.1.2.1.4.22.1", configReader.ModeWalk)
+			So(err, ShouldBeNil)
+			So(oids(results), ShouldResemble, []string{"1.3.6.1.2.1.4.22.1.2.2.10.0.0.1", "1.3.6.1.2.1.4.22.1.2.2.10.0.0.20",
+				"1.3.6.1.2.1.4.22.1.4.2.10.0.0.1"})
+		})
+
+		Convey("in table mode at the end of MIB view", func() {
+			results, err := Walk(agentSender(agent, &requests), ".1.3.6.1.2.1.4.22.1.4", configReader.ModeTable)
+			So(err, ShouldBeNil)
+			So(oids(results), ShouldResemble, []string{"1.3.6.1.2.1.4.22.1.4.2.10.0.0.1"})
+		})
+
+		Convey("in table mode when column is empty", func() {
+			results, err := Walk(agentSender(agent, &requests), ".1.3.6.1.2.1.2.2.1.3", configReader.ModeTable)
+			So(err, ShouldBeNil)
+			So(results, ShouldBeEmpty)
+		})
+	})
+}
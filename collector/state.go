@@ -0,0 +1,118 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//metricState keeps values of metrics from previous collection, it is kept with connection to SNMP agent
+type metricState struct {
+	mtx     sync.Mutex
+	samples map[string]sample
+
+	//values values of metrics which are returned on change
+	values map[string]interface{}
+
+	//sysUpTime value of sysUpTime in previous collection with rates, it is used to detect restart of SNMP agent
+	sysUpTime uint64
+}
+
+type sample struct {
+	value     interface{}
+	snmpType  string
+	timestamp time.Time
+}
+
+func newMetricState() *metricState {
+	return &metricState{samples: make(map[string]sample), values: make(map[string]interface{})}
+}
+
+//checkSysUpTime removes previous samples of metrics if sysUpTime is lower than in previous collection, counters are reset
+//by restart of SNMP agent, so rates are available again from the next collection
+func (s *metricState) checkSysUpTime(ticks uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if ticks < s.sysUpTime {
+		log.WithFields(log.Fields{"previous_sysUpTime": s.sysUpTime, "current_sysUpTime": ticks}).Debug("SNMP agent restarted, previous samples of rates are removed")
+		s.samples = make(map[string]sample)
+	}
+	s.sysUpTime = ticks
+}
+
+//rate returns per second rate of change of metric value since previous collection, wrap of 32-bit counters is taken into account,
+//false is returned if previous value is not available or 64-bit counter decreases (it does not wrap, so it was reset)
+func (s *metricState) rate(key string, value interface{}, snmpType string, timestamp time.Time) (float64, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	prev, ok := s.samples[key]
+	s.samples[key] = sample{value: value, snmpType: snmpType, timestamp: timestamp}
+	if !ok || prev.snmpType != snmpType {
+		return 0, false
+	}
+
+	seconds := timestamp.Sub(prev.timestamp).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+
+	var delta float64
+	switch current := value.(type) {
+	case uint64:
+		previous := prev.value.(uint64)
+		switch snmpType {
+		case "Counter", "Counter32":
+			delta = float64(uint32(current - previous))
+		case "Counter64":
+			if current < previous {
+				return 0, false
+			}
+			delta = float64(current - previous)
+		default:
+			delta = float64(current) - float64(previous)
+		}
+	case int64:
+		delta = float64(current - prev.value.(int64))
+	default:
+		return 0, false
+	}
+	return delta / seconds, true
+}
+
+//change checks if metric value differs from value in previous collection, previous value is returned,
+//false is returned if previous value is not available
+func (s *metricState) change(key string, value interface{}) (interface{}, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	previous, ok := s.values[key]
+	s.values[key] = value
+	if !ok {
+		return nil, false
+	}
+	return previous, previous != value
+}
@@ -0,0 +1,303 @@
+// +build linux
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/k-sone/snmpgo"
+	log "github.com/sirupsen/logrus"
+)
+
+//readMetric reads values of metric, fallback OID is read if OID is not available in SNMP agent,
+//OID of metric is replaced with fallback OID because indexes of rows are relative to OID which is read
+func readMetric(handler *snmpgo.SNMP, cfg *configReader.Metric) ([]*snmpgo.VarBind, error) {
+	results, err := snmp_.readElements(handler, cfg.Oid, cfg.Mode)
+	if cfg.FallbackOid == "" || !notAvailable(results, err) {
+		return results, err
+	}
+
+	results, err = snmp_.readElements(handler, cfg.FallbackOid, cfg.Mode)
+	if err != nil {
+		return results, err
+	}
+	cfg.Oid = cfg.FallbackOid
+	return results, nil
+}
+
+//notAvailable checks if OID is not available in SNMP agent
+func notAvailable(results []*snmpgo.VarBind, err error) bool {
+	if err != nil {
+		//SNMP v1 agent responds with noSuchName error
+		_, ok := err.(*snmp.AgentError)
+		return ok
+	}
+
+	if len(results) == 0 {
+		return true
+	}
+
+	switch results[0].Variable.Type() {
+	case "NoSucheObject", "NoSucheInstance", "EndOfMibView":
+		return true
+	}
+	return false
+}
+
+//operation modifies metric value using value read from SNMP agent, operands are read for each of results
+type operation struct {
+	operands []string
+	apply    func(value interface{}, operand string) (float64, error)
+}
+
+//readOperations reads operands of operations configured for metric, operations are returned in order in which they are applied
+func readOperations(handler *snmpgo.SNMP, cache *tableCache, results []*snmpgo.VarBind, cfg *configReader.Metric) ([]operation, error) {
+	configured := []struct {
+		oid   string
+		apply func(value interface{}, operand string) (float64, error)
+
+		//fallbackOid column which is read if operand is not available or it is not greater than 0,
+		//fallbackScale converts value of fallback column into unit of operand
+		fallbackOid   string
+		fallbackScale float64
+	}{
+		{cfg.MultiplyByOid, multiply, "", 1},
+		{cfg.DivideByOid, divide, cfg.DivideByFallbackOid, cfg.DivideByFallbackScale},
+		{cfg.ScaleOid, applyDataScale, "", 1},
+		{cfg.PrecisionOid, applyPrecision, "", 1},
+	}
+
+	operations := []operation{}
+	for _, c := range configured {
+		if c.oid == "" {
+			continue
+		}
+
+		operands, err := resolveJoin(handler, cache, results, cfg, join{oid: c.oid, join: configReader.JoinIndex})
+		if c.fallbackOid != "" {
+			operands, err = fallbackOperands(handler, cache, results, cfg, operands, err, c.fallbackOid, c.fallbackScale)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"OID": cfg.Oid, "operand_OID": c.oid}).Warn(err)
+			return nil, err
+		}
+		operations = append(operations, operation{operands: operands, apply: c.apply})
+	}
+	return operations, nil
+}
+
+//fallbackOperands replaces operands which are not available or are not greater than 0 with values of fallback column multiplied by scale
+//(e.g. ifSpeed in bit/s is used instead of ifHighSpeed in Mbit/s if ifXTable is not implemented by SNMP agent)
+func fallbackOperands(handler *snmpgo.SNMP, cache *tableCache, results []*snmpgo.VarBind, cfg *configReader.Metric,
+	operands []string, err error, fallbackOid string, scale float64) ([]string, error) {
+	fallback, fallbackErr := resolveJoin(handler, cache, results, cfg, join{oid: fallbackOid, join: configReader.JoinIndex})
+	if fallbackErr != nil {
+		return operands, err
+	}
+
+	if err != nil {
+		operands = make([]string, len(results))
+	}
+	for i := range operands {
+		if operand, err := strconv.ParseFloat(operands[i], 64); err == nil && operand > 0 {
+			continue
+		}
+		if value, err := strconv.ParseFloat(fallback[i], 64); err == nil {
+			operands[i] = strconv.FormatFloat(value*scale, 'g', -1, 64)
+		}
+	}
+	return operands, nil
+}
+
+//applyOperations applies operations to value of i-th result
+func applyOperations(value interface{}, operations []operation, i int) (interface{}, error) {
+	for _, op := range operations {
+		result, err := op.apply(value, op.operands[i])
+		if err != nil {
+			return nil, err
+		}
+		value = result
+	}
+	return value, nil
+}
+
+//multiply multiplies numeric value of metric by value read from SNMP agent
+func multiply(value interface{}, operand string) (float64, error) {
+	v, o, err := toFloats(value, operand)
+	if err != nil {
+		return 0, err
+	}
+	return v * o, nil
+}
+
+//divide divides numeric value of metric by value read from SNMP agent, divisor must be positive
+//because negative values of capacities and speeds are special values (e.g. -2 means unknown in Printer-MIB)
+func divide(value interface{}, operand string) (float64, error) {
+	v, o, err := toFloats(value, operand)
+	if err != nil {
+		return 0, err
+	}
+	if o <= 0 {
+		return 0, fmt.Errorf("Incorrect divisor (%s), it must be greater than 0", operand)
+	}
+	return v / o, nil
+}
+
+//applyDataScale multiplies value by power of 10 given as SI prefix, from yocto (1) through units (9) to yotta (17),
+//it is EntitySensorDataScale defined in ENTITY-SENSOR-MIB (RFC 3433)
+func applyDataScale(value interface{}, operand string) (float64, error) {
+	v, scale, err := toFloats(value, operand)
+	if err != nil {
+		return 0, err
+	}
+	if scale < 1 || scale > 17 {
+		return 0, fmt.Errorf("Incorrect data scale (%s)", operand)
+	}
+	return v * math.Pow10(3*(int(scale)-9)), nil
+}
+
+//applyPrecision divides value by power of 10 given as number of decimal places (from -8 to 9),
+//it is EntitySensorPrecision defined in ENTITY-SENSOR-MIB (RFC 3433)
+func applyPrecision(value interface{}, operand string) (float64, error) {
+	v, precision, err := toFloats(value, operand)
+	if err != nil {
+		return 0, err
+	}
+	if precision < -8 || precision > 9 {
+		return 0, fmt.Errorf("Incorrect precision (%s)", operand)
+	}
+	return v * math.Pow10(-int(precision)), nil
+}
+
+//toFloats converts numeric value of metric and value read from SNMP agent to float64
+func toFloats(value interface{}, operand string) (float64, float64, error) {
+	v, ok := toFloat(value)
+	if !ok {
+		return 0, 0, fmt.Errorf("Metric value is not numeric (%v)", value)
+	}
+	o, err := strconv.ParseFloat(operand, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return v, o, nil
+}
+
+//toFloat converts numeric value of metric to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case uint64:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+//decodeValue decodes octet string according to encoding, values of other types and values without encoding are returned unchanged
+func decodeValue(value snmpgo.Variable, encoding string) string {
+	octetString, ok := value.(*snmpgo.OctetString)
+	if !ok || encoding == "" {
+		return value.String()
+	}
+	octets := octetString.Value
+
+	switch encoding {
+	case configReader.ValueEncodingMAC:
+		if len(octets) == 6 {
+			return net.HardwareAddr(octets).String()
+		}
+	case configReader.ValueEncodingIP:
+		if len(octets) == net.IPv4len || len(octets) == net.IPv6len {
+			return net.IP(octets).String()
+		}
+	case configReader.ValueEncodingNetworkAddress:
+		//address family numbers are assigned by IANA, 1 - IPv4, 2 - IPv6
+		switch {
+		case len(octets) == net.IPv4len+1 && octets[0] == 1, len(octets) == net.IPv6len+1 && octets[0] == 2:
+			return net.IP(octets[1:]).String()
+		}
+	case configReader.ValueEncodingString:
+		if isPrintable(octets) {
+			return string(octets)
+		}
+	}
+	return hexOctets(octets)
+}
+
+//isPrintable checks if all octets are printable ASCII characters
+func isPrintable(octets []byte) bool {
+	for _, o := range octets {
+		if o < 0x20 || o > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+//hexOctets returns octets in hexadecimal form separated by colons
+func hexOctets(octets []byte) string {
+	hex := make([]string, len(octets))
+	for i, o := range octets {
+		hex[i] = fmt.Sprintf("%02x", o)
+	}
+	return strings.Join(hex, ":")
+}
+
+//skipValue checks if value read from SNMP agent is one of values which are not returned
+func skipValue(skipValues []string, value string) bool {
+	for _, skip := range skipValues {
+		if value == skip {
+			return true
+		}
+	}
+	return false
+}
+
+//mapMetricValue returns label of metric value, values which are not mapped are returned unchanged
+func mapMetricValue(valueMap map[string]string, value interface{}) interface{} {
+	if label, ok := valueMap[fmt.Sprint(value)]; ok {
+		return label
+	}
+	return value
+}
+
+//mapValue returns label of value, OIDs can be mapped with or without leading dot
+func mapValue(valueMap map[string]string, value string) string {
+	if label, ok := valueMap[value]; ok {
+		return label
+	}
+	if label, ok := valueMap["."+strings.TrimPrefix(value, ".")]; ok {
+		return label
+	}
+	if label, ok := valueMap[strings.TrimPrefix(value, ".")]; ok {
+		return label
+	}
+	return value
+}
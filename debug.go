@@ -0,0 +1,289 @@
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/configReader"
+	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector/snmp"
+	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
+)
+
+const (
+	//formatTable output format of collect command, metrics are printed as table
+	formatTable = "table"
+
+	//formatJSON output format of collect command, metrics are printed as JSON array
+	formatJSON = "json"
+)
+
+//debugCommands commands which are run instead of plugin, they allow to check configuration of SNMP agent and setfile without Snap
+var debugCommands = map[string]func(args []string, out io.Writer) error{
+	"get":     getCommand,
+	"walk":    walkCommand,
+	"collect": collectCommand,
+}
+
+//debugMetric metric printed by collect command
+type debugMetric struct {
+	Namespace string            `json:"namespace"`
+	Value     interface{}       `json:"value"`
+	Type      string            `json:"type"`
+	Unit      string            `json:"unit,omitempty"`
+	Tags      map[string]string `json:"tags"`
+}
+
+//byNamespace sorts metrics by namespace
+type byNamespace []debugMetric
+
+func (m byNamespace) Len() int           { return len(m) }
+func (m byNamespace) Less(i, j int) bool { return m[i].Namespace < m[j].Namespace }
+func (m byNamespace) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+
+//debugExchange exchange with SNMP agent printed by collect command
+type debugExchange struct {
+	Agent    string `json:"agent"`
+	Oid      string `json:"oid"`
+	Mode     string `json:"mode"`
+	Requests int    `json:"requests"`
+	Results  int    `json:"results"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+//runDebugCommand runs debug command if it is given as the first argument, it indicates if the command is found
+func runDebugCommand(args []string, out io.Writer) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	command, ok := debugCommands[args[0]]
+	if !ok {
+		return false, nil
+	}
+	return true, command(args[1:], out)
+}
+
+//getCommand reads scalar from SNMP agent with GET request
+func getCommand(args []string, out io.Writer) error {
+	return readCommand("get", configReader.ModeSingle, args, out)
+}
+
+//walkCommand reads subtree from SNMP agent with GETNEXT requests
+func walkCommand(args []string, out io.Writer) error {
+	return readCommand("walk", configReader.ModeWalk, args, out)
+}
+
+//readCommand reads OID from SNMP agent set in configuration file using SNMP layer of plugin and prints variable bindings
+func readCommand(name string, mode string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to JSON file with configuration of SNMP agent (parameters of plugin configuration)")
+	if name == "walk" {
+		flags.StringVar(&mode, "mode", mode, "mode of reading, walk or table")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: %s -config <file> <OID>", name)
+	}
+	if name == "walk" && mode != configReader.ModeWalk && mode != configReader.ModeTable {
+		return fmt.Errorf("Incorrect mode (%s), possible options: %v", mode, []string{configReader.ModeWalk, configReader.ModeTable})
+	}
+
+	config, err := readDebugConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	agentConfig, err := configReader.GetSnmpAgentConfig(config)
+	if err != nil {
+		return err
+	}
+
+	handler, err := snmp.NewHandler(agentConfig)
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+
+	start := time.Now()
+	results, err := snmp.ReadElements(handler, flags.Arg(0), mode)
+	duration := time.Since(start)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "OID\tTYPE\tVALUE")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Oid.String(), result.Variable.Type(), result.Variable.String())
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\n%d variable bindings read from %s in %v\n", len(results), agentConfig.Address, duration)
+	return err
+}
+
+//collectCommand collects metrics defined in setfile and profiles from SNMP agent and prints metrics with exchanges with SNMP agent
+func collectCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("collect", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to JSON file with configuration of SNMP agent (parameters of plugin configuration)")
+	setFilePath := flags.String("setfile", "", "path to setfile, overrides setfile set in configuration")
+	profileNames := flags.String("profile", "", "comma separated list of built-in profiles, overrides profile set in configuration")
+	namespace := flags.String("namespace", "/intel/snmp/*", "requested namespace, elements can be `*` or tuples")
+	format := flags.String("format", formatTable, "output format, table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != formatTable && *format != formatJSON {
+		return fmt.Errorf("Incorrect output format (%s), possible options: %v", *format, []string{formatTable, formatJSON})
+	}
+
+	config, err := readDebugConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *setFilePath != "" {
+		config["setfile"] = *setFilePath
+	}
+	if *profileNames != "" {
+		config["profile"] = *profileNames
+	}
+
+	trace, err := collector.TraceCollection(config, *namespace)
+	if err != nil {
+		return err
+	}
+
+	metrics := []debugMetric{}
+	for _, mt := range trace.Metrics {
+		metrics = append(metrics, debugMetric{Namespace: mt.Namespace.String(), Value: mt.Data, Type: fmt.Sprintf("%T", mt.Data),
+			Unit: mt.Unit, Tags: mt.Tags})
+	}
+	sort.Sort(byNamespace(metrics))
+
+	exchanges := []debugExchange{}
+	for _, e := range trace.Exchanges {
+		exchange := debugExchange{Agent: e.Agent, Oid: e.Oid, Mode: e.Mode, Requests: e.Requests, Results: len(e.Results),
+			Duration: e.Duration.String()}
+		if e.Err != nil {
+			exchange.Error = e.Err.Error()
+		}
+		exchanges = append(exchanges, exchange)
+	}
+
+	//numbers of errors by category are counted in the collection only, because the command runs a single collection
+	errorCounts := collector.ErrorCounts()
+
+	if *format == formatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{"metrics": metrics, "exchanges": exchanges, "errors": errorCounts,
+			"duration": trace.Duration.String()})
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tVALUE\tTYPE\tTAGS")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "%s\t%v\t%s\t%s\n", m.Namespace, m.Value, m.Type, formatTags(m.Tags))
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tOID\tMODE\tREQUESTS\tRESULTS\tDURATION\tERROR")
+	for _, e := range exchanges {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n", e.Agent, e.Oid, e.Mode, e.Requests, e.Results, e.Duration, e.Error)
+	}
+	w.Flush()
+	if len(errorCounts) > 0 {
+		fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "AGENT\tCATEGORY\tERRORS")
+		for _, agent := range sortedKeys(errorCounts) {
+			for _, category := range snmp.Categories {
+				if count, ok := errorCounts[agent][category]; ok {
+					fmt.Fprintf(w, "%s\t%s\t%d\n", agent, category, count)
+				}
+			}
+		}
+		w.Flush()
+	}
+	fmt.Fprintf(out, "\n%d metrics collected with %d exchanges in %v\n", len(metrics), len(exchanges), trace.Duration)
+	return nil
+}
+
+//formatTags returns tags sorted by name
+func formatTags(tags map[string]string) string {
+	keys := []string{}
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+tags[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+//sortedKeys returns addresses of SNMP agents in numbers of errors sorted
+func sortedKeys(counts map[string]map[string]uint64) []string {
+	keys := []string{}
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//readDebugConfig reads configuration of plugin from JSON file, integer numbers are converted to int64 as in configuration passed by Snap
+func readDebugConfig(path string) (plugin.Config, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Path to configuration file is required (-config)")
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("Configuration file (%s) cannot be unmarshalled: %v", path, err)
+	}
+
+	config := plugin.NewConfig()
+	for k, v := range values {
+		if number, ok := v.(float64); ok && number == math.Trunc(number) {
+			v = int64(number)
+		}
+		config[k] = v
+	}
+	return config, nil
+}
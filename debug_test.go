@@ -0,0 +1,124 @@
+// +build small
+
+/*
+http://www.apache.org/licenses/LICENSE-2.0.txt
+
+
+Copyright 2016 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func writeDebugConfig(content string) string {
+	f, err := ioutil.TempFile("", "snmp-debug-config")
+	So(err, ShouldBeNil)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	So(err, ShouldBeNil)
+	return f.Name()
+}
+
+func TestReadDebugConfig(t *testing.T) {
+	Convey("Reading configuration of debug commands", t, func() {
+		Convey("when path is not given", func() {
+			_, err := readDebugConfig("")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when file does not exist", func() {
+			_, err := readDebugConfig("/tmp/snmp-debug-config-not-existing.json")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when file is not JSON object", func() {
+			path := writeDebugConfig(`["snmp_agent_address"]`)
+			defer os.Remove(path)
+
+			_, err := readDebugConfig(path)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when file is correct", func() {
+			path := writeDebugConfig(`{"snmp_agent_address": "127.0.0.1", "snmp_version": "v2c", "retries": 3, "timeout": 1.5}`)
+			defer os.Remove(path)
+
+			config, err := readDebugConfig(path)
+			So(err, ShouldBeNil)
+			So(config["snmp_agent_address"], ShouldEqual, "127.0.0.1")
+			So(config["snmp_version"], ShouldEqual, "v2c")
+
+			//whole numbers are passed as int64 by Snap
+			So(config["retries"], ShouldEqual, int64(3))
+			So(config["timeout"], ShouldEqual, 1.5)
+		})
+	})
+}
+
+func TestRunDebugCommand(t *testing.T) {
+	Convey("Running debug commands", t, func() {
+		out := &bytes.Buffer{}
+
+		Convey("when arguments are not given, plugin is run", func() {
+			found, err := runDebugCommand([]string{}, out)
+			So(found, ShouldBeFalse)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("when the first argument is not a command, plugin is run", func() {
+			found, err := runDebugCommand([]string{"--config", "{}"}, out)
+			So(found, ShouldBeFalse)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("when OID is not given to get command", func() {
+			found, err := runDebugCommand([]string{"get", "-config", "agent.json"}, out)
+			So(found, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when configuration file is not given to get command", func() {
+			found, err := runDebugCommand([]string{"get", ".1.3.6.1.2.1.1.5.0"}, out)
+			So(found, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when mode of walk command is incorrect", func() {
+			found, err := runDebugCommand([]string{"walk", "-config", "agent.json", "-mode", "single", ".1.3.6.1.2.1.2.2.1.2"}, out)
+			So(found, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when output format of collect command is incorrect", func() {
+			found, err := runDebugCommand([]string{"collect", "-config", "agent.json", "-format", "xml"}, out)
+			So(found, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("when flag of command is unknown", func() {
+			found, err := runDebugCommand([]string{"collect", "-unknown"}, out)
+			So(found, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
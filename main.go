@@ -20,12 +20,24 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/intelsdi-x/snap-plugin-collector-snmp/collector"
 	"github.com/intelsdi-x/snap-plugin-lib-go/v1/plugin"
 )
 
 func main() {
 
+	//debug commands are run without Snap, e.g. `snap-plugin-collector-snmp collect -config agent.json -setfile setfile.json`
+	if ok, err := runDebugCommand(os.Args[1:], os.Stdout); ok {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	plg := collector.New()
 	if plg == nil {
 		panic("Plugin could not be initialized")
@@ -0,0 +1,28 @@
+#!/usr/bin/env bash
+
+# http://www.apache.org/licenses/LICENSE-2.0.txt
+#
+#
+# Copyright 2016 Intel Corporation
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Medium tests of the plugin, sourced by scripts/test.sh instead of its default medium run.
+# Concurrent collections and discovery are tested by small and medium tests, so they are run with race detector too.
+
+_info "running race detector with small and medium tests"
+go test -race --tags="small medium" ./...
+
+UNIT_TEST="go_test go_cover"
+echo "mode: count" > profile.cov
+test_unit